@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yourusername/yourrepo/db/sqlc"
+	"github.com/yourusername/yourrepo/mq/rabbitmq"
+)
+
+// Event types tracked by the sync cursors, matching the API's eventType values
+const (
+	eventTypeDeposit    = "deposit"
+	eventTypeWithdrawal = "withdrawal"
+)
+
+var eventTypes = []string{eventTypeDeposit, eventTypeWithdrawal}
+
+// Indexer decodes mixer logs and stores them, keeping the stored events in
+// line with the canonical chain.
+type Indexer struct {
+	pool      *pgxpool.Pool
+	queries   *sqlc.Queries
+	client    *ethclient.Client
+	abis      map[common.Address]abi.ABI
+	chainID   int32
+	contracts []common.Address
+
+	// confirmationDepth is how many blocks must be built on top of an event
+	// before it is marked as confirmed
+	confirmationDepth uint64
+
+	// mu serialises writes from the live stream, sync up and reorg rollbacks
+	mu sync.Mutex
+}
+
+// NewIndexer creates a new Indexer instance.
+func NewIndexer(pool *pgxpool.Pool, client *ethclient.Client, abis map[common.Address]abi.ABI, chainID int32, contracts []common.Address, confirmationDepth uint64) *Indexer {
+	return &Indexer{
+		pool:              pool,
+		queries:           sqlc.New(pool),
+		client:            client,
+		abis:              abis,
+		chainID:           chainID,
+		contracts:         contracts,
+		confirmationDepth: confirmationDepth,
+	}
+}
+
+// HandleLog stores a log delivered by the live subscription. Logs flagged as
+// removed belong to a block that was reorged out, so everything indexed from
+// that block is dropped instead. coveredFrom is the block the subscription
+// was opened at; the stream delivers every log after it.
+func (ix *Indexer) HandleLog(ctx context.Context, vLog types.Log, coveredFrom uint64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if vLog.Removed {
+		if err := ix.removeBlock(ctx, vLog.BlockHash); err != nil {
+			log.Printf("Failed to remove events of reorged block %s: %v", vLog.BlockHash.Hex(), err)
+		}
+		return
+	}
+
+	if err := ix.storeLogs(ctx, []types.Log{vLog}); err != nil {
+		log.Printf("Failed to store log %s#%d: %v", vLog.TxHash.Hex(), vLog.Index, err)
+		return
+	}
+	if err := ix.advanceCursors(ctx, coveredFrom, vLog.BlockNumber); err != nil {
+		log.Printf("Failed to advance sync cursors to block %d: %v", vLog.BlockNumber, err)
+	}
+}
+
+// advanceCursors moves the sync cursors of every contract up to the last
+// confirmed block before a live log at blockNumber. Logs arrive in block
+// order, so every log of the earlier blocks has been stored by then. A cursor
+// only moves when it has already reached coveredFrom: below that, blocks the
+// stream never saw are still waiting for the sync up or a backfill.
+func (ix *Indexer) advanceCursors(ctx context.Context, coveredFrom, blockNumber uint64) error {
+	lag := ix.confirmationDepth
+	if lag == 0 {
+		// Other logs of the same block may still be on their way
+		lag = 1
+	}
+	if blockNumber < lag || blockNumber-lag <= coveredFrom {
+		return nil
+	}
+
+	for _, contract := range ix.contracts {
+		err := ix.queries.AdvanceSyncCursors(ctx, sqlc.AdvanceSyncCursorsParams{
+			LastSyncedBlock: int32(blockNumber - lag),
+			ChainID:         ix.chainID,
+			ContractAddress: contract.Hex(),
+			CoveredFrom:     int32(coveredFrom),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeLogs stores logs in a single transaction together with their outbox
+// messages.
+func (ix *Indexer) storeLogs(ctx context.Context, logs []types.Log) error {
+	tx, err := ix.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := ix.queries.WithTx(tx)
+	for _, vLog := range logs {
+		if err := ix.storeLog(ctx, qtx, vLog); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// Backfill fetches and stores the logs of contracts between fromBlock and
+// toBlock (inclusive). The sync cursors of those contracts are advanced in the
+// same transaction, so a restart never skips or half-applies a range.
+func (ix *Indexer) Backfill(ctx context.Context, fromBlock, toBlock uint64, contracts []common.Address) error {
+	logs, err := ix.filterLogs(ctx, fromBlock, toBlock, contracts)
+	if err != nil {
+		return err
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	tx, err := ix.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := ix.queries.WithTx(tx)
+	for _, vLog := range logs {
+		if err := ix.storeLog(ctx, qtx, vLog); err != nil {
+			return err
+		}
+	}
+
+	for _, contract := range contracts {
+		for _, eventType := range eventTypes {
+			err := qtx.UpsertSyncCursor(ctx, sqlc.UpsertSyncCursorParams{
+				ChainID:         ix.chainID,
+				ContractAddress: contract.Hex(),
+				EventType:       eventType,
+				LastSyncedBlock: int32(toBlock),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to update sync cursor: %v", err)
+			}
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// BackfillRange backfills one contract from fromBlock to toBlock in chunks of
+// blockChunkSize blocks. fromBlock must not be past the contract's
+// ResumeBlock, or the cursor would skip blocks that were never synced.
+func (ix *Indexer) BackfillRange(ctx context.Context, contract common.Address, fromBlock, toBlock, blockChunkSize uint64) error {
+	for currentBlock := fromBlock; currentBlock <= toBlock; {
+		endBlock := currentBlock + blockChunkSize
+		if endBlock > toBlock {
+			endBlock = toBlock
+		}
+		if err := ix.Backfill(ctx, currentBlock, endBlock, []common.Address{contract}); err != nil {
+			return err
+		}
+		currentBlock = endBlock + 1
+	}
+	return nil
+}
+
+// ResumeBlock returns the first block that still has to be synced for
+// contract, i.e. the block after the lowest cursor of its event types.
+func (ix *Indexer) ResumeBlock(ctx context.Context, contract common.Address, startBlock uint64) (uint64, error) {
+	resume := uint64(0)
+	for i, eventType := range eventTypes {
+		next := startBlock
+		cursor, err := ix.queries.GetSyncCursor(ctx, sqlc.GetSyncCursorParams{
+			ChainID:         ix.chainID,
+			ContractAddress: contract.Hex(),
+			EventType:       eventType,
+		})
+		if err != nil && !isNoRows(err) {
+			return 0, err
+		}
+		if err == nil && uint64(cursor.LastSyncedBlock)+1 > next {
+			next = uint64(cursor.LastSyncedBlock) + 1
+		}
+		if i == 0 || next < resume {
+			resume = next
+		}
+	}
+	return resume, nil
+}
+
+func (ix *Indexer) filterLogs(ctx context.Context, fromBlock, toBlock uint64, contracts []common.Address) ([]types.Log, error) {
+	logs, err := ix.client.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		ToBlock:   new(big.Int).SetUint64(toBlock),
+		Addresses: contracts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter logs: %v", err)
+	}
+	return logs, nil
+}
+
+// removeBlock deletes all events that were indexed from the given block.
+func (ix *Indexer) removeBlock(ctx context.Context, blockHash common.Hash) error {
+	tx, err := ix.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := ix.queries.WithTx(tx)
+	chainID := pgtype.Int4{Int32: ix.chainID, Valid: true}
+	hash := pgtype.Text{String: blockHash.Hex(), Valid: true}
+
+	deposits, err := qtx.DeleteDepositsByBlockHash(ctx, sqlc.DeleteDepositsByBlockHashParams{ChainID: chainID, BlockHash: hash})
+	if err != nil {
+		return err
+	}
+	withdrawals, err := qtx.DeleteWithdrawalsByBlockHash(ctx, sqlc.DeleteWithdrawalsByBlockHashParams{ChainID: chainID, BlockHash: hash})
+	if err != nil {
+		return err
+	}
+	if deposits > 0 || withdrawals > 0 {
+		err := enqueue(ctx, qtx, rabbitmq.RoutingKeyReorg, rabbitmq.ReorgEvent{
+			ChainID:     ix.chainID,
+			BlockHash:   blockHash.Hex(),
+			Deposits:    deposits,
+			Withdrawals: withdrawals,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	log.Printf("Removed %d deposits and %d withdrawals from reorged block %s", deposits, withdrawals, blockHash.Hex())
+	return nil
+}
+
+// storeLog decodes a Deposit or Withdrawal log and inserts it through q,
+// queueing an outbox message when the event is new. q must be bound to a
+// transaction so the row and its message commit together. Logs that cannot be
+// decoded are skipped; only database errors are returned.
+func (ix *Indexer) storeLog(ctx context.Context, q *sqlc.Queries, vLog types.Log) error {
+	if len(vLog.Topics) == 0 {
+		log.Printf("Skipping log without topics: %s#%d", vLog.TxHash.Hex(), vLog.Index)
+		return nil
+	}
+	parsedABI, ok := ix.abis[vLog.Address]
+	if !ok {
+		log.Printf("Skipping log of unregistered contract %s", vLog.Address.Hex())
+		return nil
+	}
+	event, err := parsedABI.EventByID(vLog.Topics[0])
+	if err != nil {
+		log.Printf("Unknown event: %v", err)
+		return nil
+	}
+
+	switch event.Name {
+	case "Deposit":
+		data := make(map[string]interface{})
+		if err := parsedABI.UnpackIntoMap(data, event.Name, vLog.Data); err != nil {
+			log.Printf("Failed to unpack event: %v", err)
+			return nil
+		}
+		// Extract indexed parameters
+		commitment := common.BytesToHash(vLog.Topics[1][:]).Hex()
+		depositor := common.BytesToAddress(vLog.Topics[2][:]).Hex()
+
+		// Extract non-indexed parameters
+		leafIndex := uint32(0)
+		if leafIndexVal, ok := data["leafIndex"].(uint32); ok {
+			leafIndex = leafIndexVal
+		}
+		timestampVal, ok := data["timestamp"].(*big.Int)
+		if !ok {
+			log.Printf("Missing timestamp in Deposit event: %s", vLog.TxHash.Hex())
+			return nil
+		}
+
+		deposit, err := q.CreateDeposit(ctx, sqlc.CreateDepositParams{
+			ContractAddress: pgtype.Text{String: vLog.Address.Hex(), Valid: true},
+			Commitment:      pgtype.Text{String: commitment, Valid: true},
+			Depositor:       pgtype.Text{String: depositor, Valid: true},
+			LeafIndex:       pgtype.Int4{Int32: int32(leafIndex), Valid: true},
+			Timestamp:       pgtype.Numeric{Int: timestampVal, Valid: true},
+			TxHash:          pgtype.Text{String: vLog.TxHash.Hex(), Valid: true},
+			BlockNumber:     pgtype.Int4{Int32: int32(vLog.BlockNumber), Valid: true},
+			ChainID:         pgtype.Int4{Int32: ix.chainID, Valid: true},
+			BlockHash:       pgtype.Text{String: vLog.BlockHash.Hex(), Valid: true},
+			LogIndex:        pgtype.Int4{Int32: int32(vLog.Index), Valid: true},
+		})
+		if err != nil && !isNoRows(err) {
+			return fmt.Errorf("failed to insert deposit: %v", err)
+		}
+		if deposit.ID != 0 {
+			log.Printf("Deposit event stored: commitment=%s, depositor=%s, timestamp=%s, txHash=%s", commitment, depositor, timestampVal.String(), vLog.TxHash.Hex())
+			return enqueue(ctx, q, rabbitmq.RoutingKeyDeposit, rabbitmq.DepositEvent{
+				ChainID:         ix.chainID,
+				ContractAddress: vLog.Address.Hex(),
+				Commitment:      commitment,
+				Depositor:       depositor,
+				LeafIndex:       leafIndex,
+				Timestamp:       timestampVal.String(),
+				TxHash:          vLog.TxHash.Hex(),
+				BlockNumber:     vLog.BlockNumber,
+				BlockHash:       vLog.BlockHash.Hex(),
+				LogIndex:        vLog.Index,
+			})
+		}
+	case "Withdrawal":
+		// Extract indexed parameter
+		relayer := common.BytesToAddress(vLog.Topics[1][:]).Hex()
+
+		// For Withdrawal events, data contains:
+		// [0:32]   - recipient (address)
+		// [32:64]  - nullifierHash (bytes32)
+		// [64:96]  - fee (uint256)
+		if len(vLog.Data) < 96 {
+			log.Printf("Invalid data length for Withdrawal event: %d", len(vLog.Data))
+			return nil
+		}
+		recipient := common.BytesToAddress(vLog.Data[12:32]).Hex()
+		nullifier := "0x" + hex.EncodeToString(vLog.Data[32:64])
+		fee := new(big.Int).SetBytes(vLog.Data[64:96])
+
+		withdrawal, err := q.CreateWithdrawal(ctx, sqlc.CreateWithdrawalParams{
+			ContractAddress: pgtype.Text{String: vLog.Address.Hex(), Valid: true},
+			NullifierHash:   pgtype.Text{String: nullifier, Valid: true},
+			Recipient:       pgtype.Text{String: recipient, Valid: true},
+			Relayer:         pgtype.Text{String: relayer, Valid: true},
+			Fee:             pgtype.Numeric{Int: fee, Valid: true},
+			Timestamp:       pgtype.Numeric{Int: big.NewInt(int64(vLog.BlockNumber)), Valid: true},
+			TxHash:          pgtype.Text{String: vLog.TxHash.Hex(), Valid: true},
+			BlockNumber:     pgtype.Int4{Int32: int32(vLog.BlockNumber), Valid: true},
+			ChainID:         pgtype.Int4{Int32: ix.chainID, Valid: true},
+			BlockHash:       pgtype.Text{String: vLog.BlockHash.Hex(), Valid: true},
+			LogIndex:        pgtype.Int4{Int32: int32(vLog.Index), Valid: true},
+		})
+		if err != nil && !isNoRows(err) {
+			return fmt.Errorf("failed to insert withdrawal: %v", err)
+		}
+		if withdrawal.ID != 0 {
+			log.Printf("Withdrawal event stored: nullifierHash=%s, recipient=%s, relayer=%s, fee=%s, txHash=%s",
+				nullifier, recipient, relayer, fee.String(), vLog.TxHash.Hex())
+			return enqueue(ctx, q, rabbitmq.RoutingKeyWithdrawal, rabbitmq.WithdrawalEvent{
+				ChainID:         ix.chainID,
+				ContractAddress: vLog.Address.Hex(),
+				NullifierHash:   nullifier,
+				Recipient:       recipient,
+				Relayer:         relayer,
+				Fee:             fee.String(),
+				TxHash:          vLog.TxHash.Hex(),
+				BlockNumber:     vLog.BlockNumber,
+				BlockHash:       vLog.BlockHash.Hex(),
+				LogIndex:        vLog.Index,
+			})
+		}
+	}
+	return nil
+}
+
+// enqueue writes a message to the event outbox through q.
+func enqueue(ctx context.Context, q *sqlc.Queries, routingKey string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %v", routingKey, err)
+	}
+	if err := q.CreateOutboxEvent(ctx, sqlc.CreateOutboxEventParams{RoutingKey: routingKey, Payload: body}); err != nil {
+		return fmt.Errorf("failed to queue %s message: %v", routingKey, err)
+	}
+	return nil
+}
+
+// isNoRows reports whether an insert was skipped by ON CONFLICT DO NOTHING.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yourusername/yourrepo/db/sqlc"
+	"github.com/yourusername/yourrepo/mq/rabbitmq"
+)
+
+// Outbox relay settings
+const (
+	outboxPollInterval = time.Second
+	outboxBatchSize    = 100
+	// Published messages are kept this long for debugging before being pruned
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+// OutboxRelay publishes the messages written to event_outbox to the
+// blockchain_exchange. A message is marked as published only after the broker
+// confirmed it, so delivery is at least once and in insertion order.
+type OutboxRelay struct {
+	pool        *pgxpool.Pool
+	queries     *sqlc.Queries
+	rabbitmqURL string
+	producer    *rabbitmq.Producer
+}
+
+// NewOutboxRelay creates a new OutboxRelay instance.
+func NewOutboxRelay(pool *pgxpool.Pool, rabbitmqURL string) *OutboxRelay {
+	return &OutboxRelay{
+		pool:        pool,
+		queries:     sqlc.New(pool),
+		rabbitmqURL: rabbitmqURL,
+	}
+}
+
+// Run relays pending messages until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	defer r.closeProducer()
+
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+	lastPrune := time.Time{}
+
+	for {
+		for {
+			published, err := r.relayBatch(ctx)
+			if err != nil {
+				log.Printf("Failed to relay outbox messages: %v", err)
+				r.closeProducer()
+				break
+			}
+			if published < outboxBatchSize {
+				break
+			}
+		}
+
+		if time.Since(lastPrune) > time.Hour {
+			cutoff := pgtype.Timestamp{Time: time.Now().Add(-outboxRetention), Valid: true}
+			if err := r.queries.PrunePublishedOutboxEvents(ctx, cutoff); err != nil {
+				log.Printf("Failed to prune outbox: %v", err)
+			}
+			lastPrune = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relayBatch publishes up to outboxBatchSize pending messages and returns how
+// many were published. Rows are locked while they are published so several
+// listener instances can share the outbox.
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+	events, err := qtx.GetPendingOutboxEvents(ctx, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	producer, err := r.connect()
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	var publishErr error
+	for _, event := range events {
+		if err := producer.PublishStructContext(ctx, event.RoutingKey, json.RawMessage(event.Payload)); err != nil {
+			// Stop at the first failure to keep messages in order
+			publishErr = fmt.Errorf("failed to publish outbox message %d: %v", event.ID, err)
+			break
+		}
+		if err := qtx.MarkOutboxEventPublished(ctx, event.ID); err != nil {
+			return 0, err
+		}
+		published++
+	}
+
+	// Commit what went out even if a later message failed, so it is not resent
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	if published > 0 {
+		log.Printf("Published %d chain events to %s", published, rabbitmq.BlockchainExchange)
+	}
+	return published, publishErr
+}
+
+// connect returns the current producer, dialling RabbitMQ if needed.
+func (r *OutboxRelay) connect() (*rabbitmq.Producer, error) {
+	if r.producer != nil {
+		return r.producer, nil
+	}
+	producer, err := rabbitmq.NewProducer(r.rabbitmqURL, rabbitmq.BlockchainExchange, "topic")
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %v", err)
+	}
+	if err := producer.EnableConfirms(); err != nil {
+		producer.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %v", err)
+	}
+	log.Printf("Connected to RabbitMQ exchange %s", rabbitmq.BlockchainExchange)
+	r.producer = producer
+	return producer, nil
+}
+
+func (r *OutboxRelay) closeProducer() {
+	if r.producer != nil {
+		r.producer.Close()
+		r.producer = nil
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v3"
+)
+
+// Default number of blocks requested per FilterLogs call during sync up
+const defaultBlockChunkSize = 499
+
+// Registry lists the chains and mixer contracts indexed by the listener.
+type Registry struct {
+	Chains []ChainConfig `json:"chains" yaml:"chains"`
+}
+
+// ChainConfig describes one chain and the contracts deployed on it.
+type ChainConfig struct {
+	ChainID           int32            `json:"chain_id" yaml:"chain_id"`
+	Name              string           `json:"name" yaml:"name"`
+	WebsocketURL      string           `json:"ws_url" yaml:"ws_url"`
+	RPCURL            string           `json:"http_url" yaml:"http_url"`
+	DeploymentBlock   uint64           `json:"deployment_block" yaml:"deployment_block"`
+	ConfirmationDepth *uint64          `json:"confirmation_depth,omitempty" yaml:"confirmation_depth,omitempty"`
+	BlockChunkSize    uint64           `json:"block_chunk_size,omitempty" yaml:"block_chunk_size,omitempty"`
+	Contracts         []ContractConfig `json:"contracts" yaml:"contracts"`
+}
+
+// ContractConfig describes a single mixer pool.
+type ContractConfig struct {
+	Address      string `json:"address" yaml:"address"`
+	Denomination string `json:"denomination" yaml:"denomination"`
+	Token        string `json:"token" yaml:"token"`
+	// ABI is the path of the contract's ABI JSON file; the built-in mixer ABI is used when empty
+	ABI string `json:"abi,omitempty" yaml:"abi,omitempty"`
+	// DeploymentBlock overrides the chain's deployment block for pools deployed later
+	DeploymentBlock uint64 `json:"deployment_block,omitempty" yaml:"deployment_block,omitempty"`
+}
+
+// LoadRegistry reads a YAML (.yaml, .yml) or JSON contract registry. ${VAR}
+// references in the file are expanded from the environment so RPC credentials
+// can stay out of it.
+func LoadRegistry(path string) (*Registry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contract registry: %v", err)
+	}
+	expanded := []byte(os.ExpandEnv(string(raw)))
+
+	var registry Registry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(expanded, &registry)
+	default:
+		err = json.Unmarshal(expanded, &registry)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse contract registry: %v", err)
+	}
+	if err := registry.validate(); err != nil {
+		return nil, err
+	}
+	return &registry, nil
+}
+
+// RegistryFromEnv builds a single-chain registry from the legacy MIXER_* variables.
+func RegistryFromEnv() (*Registry, error) {
+	chain := ChainConfig{
+		ChainID:      roninChainID,
+		Name:         "ronin-saigon",
+		WebsocketURL: os.Getenv("RONIN_WEBSOCKET_URL"),
+		RPCURL:       os.Getenv("RPC_URL"),
+	}
+
+	for _, mixer := range []struct{ env, denomination string }{
+		{"MIXER_0_1", "0.1"},
+		{"MIXER_1", "1"},
+		{"MIXER_10", "10"},
+		{"MIXER_100", "100"},
+	} {
+		address := os.Getenv(mixer.env)
+		log.Printf("Mixer %s Address: %s", mixer.denomination, address)
+		chain.Contracts = append(chain.Contracts, ContractConfig{
+			Address:      address,
+			Denomination: mixer.denomination,
+			Token:        "RON",
+		})
+	}
+
+	startBlock := os.Getenv("DEPLOYMENT_BLOCK")
+	if startBlock == "" {
+		return nil, fmt.Errorf("DEPLOYMENT_BLOCK environment variable is not set")
+	}
+	deploymentBlock, err := strconv.ParseUint(startBlock, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse start block: %v", err)
+	}
+	chain.DeploymentBlock = deploymentBlock
+
+	if v := os.Getenv("CONFIRMATION_DEPTH"); v != "" {
+		depth, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CONFIRMATION_DEPTH: %v", err)
+		}
+		chain.ConfirmationDepth = &depth
+	}
+
+	registry := &Registry{Chains: []ChainConfig{chain}}
+	if err := registry.validate(); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+func (r *Registry) validate() error {
+	if len(r.Chains) == 0 {
+		return fmt.Errorf("contract registry has no chains")
+	}
+	seen := make(map[int32]bool)
+	for _, chain := range r.Chains {
+		if chain.ChainID == 0 {
+			return fmt.Errorf("chain %q has no chain_id", chain.Name)
+		}
+		if seen[chain.ChainID] {
+			return fmt.Errorf("chain %d is listed twice", chain.ChainID)
+		}
+		seen[chain.ChainID] = true
+
+		if chain.WebsocketURL == "" || chain.RPCURL == "" {
+			return fmt.Errorf("chain %d needs both ws_url and http_url", chain.ChainID)
+		}
+		if len(chain.Contracts) == 0 {
+			return fmt.Errorf("chain %d has no contracts", chain.ChainID)
+		}
+		for _, contract := range chain.Contracts {
+			if !common.IsHexAddress(contract.Address) {
+				return fmt.Errorf("chain %d: invalid contract address %q", chain.ChainID, contract.Address)
+			}
+		}
+	}
+	return nil
+}
+
+// Addresses returns the contract addresses of the chain.
+func (c ChainConfig) Addresses() []common.Address {
+	addresses := make([]common.Address, len(c.Contracts))
+	for i, contract := range c.Contracts {
+		addresses[i] = common.HexToAddress(contract.Address)
+	}
+	return addresses
+}
+
+// confirmationDepth returns the configured depth or the listener default.
+func (c ChainConfig) confirmationDepth() uint64 {
+	if c.ConfirmationDepth != nil {
+		return *c.ConfirmationDepth
+	}
+	return defaultConfirmationDepth
+}
+
+// blockChunkSize returns the configured chunk size or the listener default.
+func (c ChainConfig) blockChunkSize() uint64 {
+	if c.BlockChunkSize > 0 {
+		return c.BlockChunkSize
+	}
+	return defaultBlockChunkSize
+}
+
+// startBlock returns the block sync up starts from for contract.
+func (c ChainConfig) startBlock(contract ContractConfig) uint64 {
+	if contract.DeploymentBlock > 0 {
+		return contract.DeploymentBlock
+	}
+	return c.DeploymentBlock
+}
+
+// ABIs parses the ABI of every contract of the chain, keyed by address.
+func (c ChainConfig) ABIs() (map[common.Address]abi.ABI, error) {
+	defaultABI, err := abi.JSON(strings.NewReader(contractABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse contract ABI: %v", err)
+	}
+
+	abis := make(map[common.Address]abi.ABI, len(c.Contracts))
+	for _, contract := range c.Contracts {
+		address := common.HexToAddress(contract.Address)
+		if contract.ABI == "" {
+			abis[address] = defaultABI
+			continue
+		}
+		raw, err := os.ReadFile(contract.ABI)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ABI of %s: %v", contract.Address, err)
+		}
+		parsed, err := abi.JSON(strings.NewReader(string(raw)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ABI of %s: %v", contract.Address, err)
+		}
+		abis[address] = parsed
+	}
+	return abis, nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/yourusername/yourrepo/db/sqlc"
+	"github.com/yourusername/yourrepo/mq/rabbitmq"
+)
+
+// headerHistorySize is how many recent canonical headers are kept for reorg detection.
+const headerHistorySize = 256
+
+// TrackHeads follows new chain heads, records them in block_headers and rolls
+// back indexed events whenever the stored chain stops matching the canonical one.
+func (ix *Indexer) TrackHeads(ctx context.Context, wsClient *ethclient.Client) error {
+	heads := make(chan *types.Header)
+	sub, err := wsClient.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to new heads: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-sub.Err():
+			return fmt.Errorf("head subscription error: %v", err)
+		case header := <-heads:
+			if err := ix.processHeader(ctx, header); err != nil {
+				log.Printf("Failed to process block header %d: %v", header.Number.Uint64(), err)
+			}
+		}
+	}
+}
+
+// processHeader records a new head and handles a reorg if the head does not
+// extend the chain we have stored.
+func (ix *Indexer) processHeader(ctx context.Context, header *types.Header) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	number := header.Number.Uint64()
+
+	if ix.isReorg(ctx, header) {
+		ancestor, err := ix.findCommonAncestor(ctx, number-1)
+		if err != nil {
+			return fmt.Errorf("failed to find common ancestor: %v", err)
+		}
+		log.Printf("Chain reorg detected at block %d (%s), rolling back to block %d", number, header.Hash().Hex(), ancestor)
+
+		if err := ix.rollback(ctx, ancestor); err != nil {
+			return fmt.Errorf("failed to roll back to block %d: %v", ancestor, err)
+		}
+		// The new head is re-ingested as well: its live logs may already have
+		// been stored and were just rolled back with the orphaned blocks.
+		if err := ix.reingest(ctx, ancestor+1, number); err != nil {
+			return fmt.Errorf("failed to re-ingest blocks %d to %d: %v", ancestor+1, number, err)
+		}
+	}
+
+	if err := ix.saveHeader(ctx, header); err != nil {
+		return err
+	}
+
+	if err := ix.confirmUpTo(ctx, number); err != nil {
+		return fmt.Errorf("failed to confirm events: %v", err)
+	}
+
+	if number > headerHistorySize {
+		return ix.queries.PruneBlockHeaders(ctx, sqlc.PruneBlockHeadersParams{
+			ChainID:     ix.chainID,
+			BlockNumber: int32(number - headerHistorySize),
+		})
+	}
+	return nil
+}
+
+// isReorg reports whether header conflicts with the stored headers, either by
+// replacing a block we already saw or by pointing at a different parent.
+func (ix *Indexer) isReorg(ctx context.Context, header *types.Header) bool {
+	number := header.Number.Uint64()
+
+	stored, err := ix.queries.GetBlockHeader(ctx, sqlc.GetBlockHeaderParams{ChainID: ix.chainID, BlockNumber: int32(number)})
+	if err == nil && stored.BlockHash != header.Hash().Hex() {
+		return true
+	}
+
+	if number == 0 {
+		return false
+	}
+	parent, err := ix.queries.GetBlockHeader(ctx, sqlc.GetBlockHeaderParams{ChainID: ix.chainID, BlockNumber: int32(number - 1)})
+	return err == nil && parent.BlockHash != header.ParentHash.Hex()
+}
+
+// findCommonAncestor walks back from block number until the stored header
+// matches the canonical one. Blocks older than the tracked history are assumed
+// to be canonical.
+func (ix *Indexer) findCommonAncestor(ctx context.Context, number uint64) (uint64, error) {
+	for ; number > 0; number-- {
+		stored, err := ix.queries.GetBlockHeader(ctx, sqlc.GetBlockHeaderParams{ChainID: ix.chainID, BlockNumber: int32(number)})
+		if err != nil {
+			if isNoRows(err) {
+				return number, nil
+			}
+			return 0, err
+		}
+
+		canonical, err := ix.client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return 0, err
+		}
+		if canonical.Hash().Hex() == stored.BlockHash {
+			return number, nil
+		}
+	}
+	return 0, nil
+}
+
+// rollback deletes every event and header recorded after block ancestor and
+// rewinds the sync cursors to it.
+func (ix *Indexer) rollback(ctx context.Context, ancestor uint64) error {
+	tx, err := ix.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := ix.queries.WithTx(tx)
+	chainID := pgtype.Int4{Int32: ix.chainID, Valid: true}
+	blockNumber := pgtype.Int4{Int32: int32(ancestor), Valid: true}
+
+	deposits, err := qtx.DeleteDepositsAfterBlock(ctx, sqlc.DeleteDepositsAfterBlockParams{ChainID: chainID, BlockNumber: blockNumber})
+	if err != nil {
+		return err
+	}
+	withdrawals, err := qtx.DeleteWithdrawalsAfterBlock(ctx, sqlc.DeleteWithdrawalsAfterBlockParams{ChainID: chainID, BlockNumber: blockNumber})
+	if err != nil {
+		return err
+	}
+	if err := qtx.DeleteBlockHeadersAfter(ctx, sqlc.DeleteBlockHeadersAfterParams{ChainID: ix.chainID, BlockNumber: int32(ancestor)}); err != nil {
+		return err
+	}
+	if err := qtx.RewindSyncCursors(ctx, sqlc.RewindSyncCursorsParams{ChainID: ix.chainID, LastSyncedBlock: int32(ancestor)}); err != nil {
+		return err
+	}
+	if deposits > 0 || withdrawals > 0 {
+		err := enqueue(ctx, qtx, rabbitmq.RoutingKeyReorg, rabbitmq.ReorgEvent{
+			ChainID:     ix.chainID,
+			AfterBlock:  ancestor,
+			Deposits:    deposits,
+			Withdrawals: withdrawals,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	log.Printf("Rolled back %d deposits and %d withdrawals after block %d", deposits, withdrawals, ancestor)
+	return nil
+}
+
+// reingest stores the canonical headers and logs of blocks fromBlock to toBlock.
+// The caller must hold ix.mu.
+func (ix *Indexer) reingest(ctx context.Context, fromBlock, toBlock uint64) error {
+	if fromBlock > toBlock {
+		return nil
+	}
+
+	for number := fromBlock; number <= toBlock; number++ {
+		header, err := ix.client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return err
+		}
+		if err := ix.saveHeader(ctx, header); err != nil {
+			return err
+		}
+	}
+
+	logs, err := ix.filterLogs(ctx, fromBlock, toBlock, ix.contracts)
+	if err != nil {
+		return err
+	}
+	if err := ix.storeLogs(ctx, logs); err != nil {
+		return err
+	}
+	log.Printf("Re-ingested %d logs from blocks %d to %d", len(logs), fromBlock, toBlock)
+	return nil
+}
+
+// confirmUpTo marks every event buried at least confirmationDepth blocks below
+// head as confirmed.
+func (ix *Indexer) confirmUpTo(ctx context.Context, head uint64) error {
+	if head < ix.confirmationDepth {
+		return nil
+	}
+	chainID := pgtype.Int4{Int32: ix.chainID, Valid: true}
+	blockNumber := pgtype.Int4{Int32: int32(head - ix.confirmationDepth), Valid: true}
+
+	deposits, err := ix.queries.ConfirmDepositsUpToBlock(ctx, sqlc.ConfirmDepositsUpToBlockParams{ChainID: chainID, BlockNumber: blockNumber})
+	if err != nil {
+		return err
+	}
+	withdrawals, err := ix.queries.ConfirmWithdrawalsUpToBlock(ctx, sqlc.ConfirmWithdrawalsUpToBlockParams{ChainID: chainID, BlockNumber: blockNumber})
+	if err != nil {
+		return err
+	}
+	if deposits > 0 || withdrawals > 0 {
+		log.Printf("Confirmed %d deposits and %d withdrawals up to block %d", deposits, withdrawals, head-ix.confirmationDepth)
+	}
+	return nil
+}
+
+func (ix *Indexer) saveHeader(ctx context.Context, header *types.Header) error {
+	return ix.queries.UpsertBlockHeader(ctx, sqlc.UpsertBlockHeaderParams{
+		ChainID:     ix.chainID,
+		BlockNumber: int32(header.Number.Uint64()),
+		BlockHash:   header.Hash().Hex(),
+		ParentHash:  header.ParentHash.Hex(),
+	})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Backoff bounds used when re-establishing a websocket subscription
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// healthyConnection is how long a subscription has to stay up before the
+// reconnect backoff is reset.
+const healthyConnection = time.Minute
+
+// supervise runs fn until ctx is cancelled, restarting it with exponential
+// backoff whenever it returns.
+func supervise(ctx context.Context, name string, fn func(ctx context.Context) error) {
+	delay := minReconnectDelay
+	for {
+		started := time.Now()
+		err := fn(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > healthyConnection {
+			delay = minReconnectDelay
+		}
+
+		log.Printf("%s stopped: %v, reconnecting in %s", name, err, delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// followChain keeps the live log subscription of a chain alive. The first
+// subscription starts the sync up to the block it was opened at; after every
+// reconnect the blocks missed while disconnected are backfilled through
+// FilterLogs before streaming resumes.
+func followChain(ctx context.Context, indexer *Indexer, chain ChainConfig) {
+	// lastBlock is the highest block known to be covered by the live stream
+	var lastBlock uint64
+
+	supervise(ctx, fmt.Sprintf("[chain %d] Log subscription", chain.ChainID), func(ctx context.Context) error {
+		client, err := ethclient.DialContext(ctx, chain.WebsocketURL)
+		if err != nil {
+			return fmt.Errorf("failed to connect to Ethereum RPC: %v", err)
+		}
+		defer client.Close()
+
+		query := ethereum.FilterQuery{
+			Addresses: chain.Addresses(),
+		}
+
+		logs := make(chan types.Log)
+		sub, err := client.SubscribeFilterLogs(ctx, query, logs)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to contract events: %v", err)
+		}
+		defer sub.Unsubscribe()
+
+		// Subscribe first and backfill afterwards so no block falls between the
+		// two; anything fetched twice is ignored by the inserts.
+		head, err := indexer.client.BlockNumber(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get latest block number: %v", err)
+		}
+		if lastBlock == 0 {
+			// Sync up to the subscription head so no block falls between them
+			go func() {
+				log.Printf("[chain %d] Starting missed events sync in background...", chain.ChainID)
+				SyncUpEvents(ctx, indexer, chain, head)
+			}()
+		} else if head > lastBlock {
+			log.Printf("[chain %d] Backfilling blocks up to %d missed while disconnected", chain.ChainID, head)
+			if err := backfillMissed(ctx, indexer, chain, head); err != nil {
+				return fmt.Errorf("failed to backfill missed blocks: %v", err)
+			}
+		}
+		if head > lastBlock {
+			lastBlock = head
+		}
+		log.Printf("[chain %d] Listening for Deposit and Withdrawal events...", chain.ChainID)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case err := <-sub.Err():
+				return fmt.Errorf("subscription error: %v", err)
+			case vLog := <-logs:
+				indexer.HandleLog(ctx, vLog, head)
+				if !vLog.Removed && vLog.BlockNumber > lastBlock {
+					lastBlock = vLog.BlockNumber
+				}
+			}
+		}
+	})
+}
+
+// backfillMissed backfills every contract of chain up to head. Each contract
+// starts at its own ResumeBlock rather than where the live stream stopped:
+// its sync up may still be running, and advancing its cursor past blocks the
+// sync up has not reached would lose them after a restart. Blocks synced by
+// both are ignored by the inserts.
+func backfillMissed(ctx context.Context, indexer *Indexer, chain ChainConfig, head uint64) error {
+	for _, contractConfig := range chain.Contracts {
+		contract := common.HexToAddress(contractConfig.Address)
+		fromBlock, err := indexer.ResumeBlock(ctx, contract, chain.startBlock(contractConfig))
+		if err != nil {
+			return fmt.Errorf("failed to read sync cursor of %s: %v", contract.Hex(), err)
+		}
+		if err := indexer.BackfillRange(ctx, contract, fromBlock, head, chain.blockChunkSize()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trackChainHeads keeps the head subscription used for reorg detection and
+// confirmations alive.
+func trackChainHeads(ctx context.Context, indexer *Indexer, chain ChainConfig) {
+	supervise(ctx, fmt.Sprintf("[chain %d] Head tracking", chain.ChainID), func(ctx context.Context) error {
+		client, err := ethclient.DialContext(ctx, chain.WebsocketURL)
+		if err != nil {
+			return fmt.Errorf("failed to connect to Ethereum RPC: %v", err)
+		}
+		defer client.Close()
+
+		return indexer.TrackHeads(ctx, client)
+	})
+}
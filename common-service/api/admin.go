@@ -0,0 +1,271 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"common-service/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/yourusername/yourrepo/db/sqlc"
+)
+
+// IssueStaffToken exchanges the API key or token of a staff member for a new
+// bearer token carrying their role, e.g. for the review dashboard.
+func (h *Handler) IssueStaffToken(c *gin.Context) {
+	principal := staffPrincipal(c)
+	token, expiresAt, err := h.sessions.Issue(auth.Claims{Subject: principal.Name, Role: principal.Role})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue token"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"name":       principal.Name,
+		"role":       principal.Role,
+		"expires_at": expiresAt.Unix(),
+	})
+}
+
+type PendingKYCQueryParams struct {
+	Limit  int32 `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset int32 `form:"offset" binding:"omitempty,min=0"`
+}
+
+// ListPendingKYC returns the KYC submissions waiting for a review, oldest first.
+func (h *Handler) ListPendingKYC(c *gin.Context) {
+	var queryParams PendingKYCQueryParams
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if queryParams.Limit == 0 {
+		queryParams.Limit = 100
+	}
+
+	submissions, err := h.repo.ListPendingKYC(c.Request.Context(), queryParams.Limit, queryParams.Offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"submissions": submissions,
+		"count":       len(submissions),
+	})
+}
+
+type StatusChangeRequest struct {
+	Reason string `json:"reason"`
+}
+
+// StartReview moves a submission to under_review so other verifiers know it
+// is being looked at.
+func (h *Handler) StartReview(c *gin.Context) {
+	h.changeStatus(c, sqlc.KycStatusUnderReview, false)
+}
+
+// ApproveKYC approves a submission under review and queues the mint of its NFT.
+func (h *Handler) ApproveKYC(c *gin.Context) {
+	kyc, ok := h.changeStatus(c, sqlc.KycStatusApproved, false)
+	if !ok {
+		return
+	}
+	wallets, err := h.publishMint(c, *kyc)
+	if err != nil {
+		log.Printf("Failed to queue mint (request %s): %v", requestID(c), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "KYC approved but the mint could not be queued, retry it"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"citizen_id":      kyc.CitizenID,
+		"status":          kyc.Status,
+		"mint_queued_for": wallets,
+	})
+}
+
+// RejectKYC rejects a submission under review. A reason is required.
+func (h *Handler) RejectKYC(c *gin.Context) {
+	if kyc, ok := h.changeStatus(c, sqlc.KycStatusRejected, true); ok {
+		c.JSON(http.StatusOK, gin.H{"citizen_id": kyc.CitizenID, "status": kyc.Status})
+	}
+}
+
+// SuspendKYC suspends an active KYC record. A reason is required.
+func (h *Handler) SuspendKYC(c *gin.Context) {
+	if kyc, ok := h.changeStatus(c, sqlc.KycStatusSuspended, true); ok {
+		c.JSON(http.StatusOK, gin.H{"citizen_id": kyc.CitizenID, "status": kyc.Status})
+	}
+}
+
+// ReinstateKYC makes a suspended KYC record active again.
+func (h *Handler) ReinstateKYC(c *gin.Context) {
+	if kyc, ok := h.changeStatus(c, sqlc.KycStatusActive, false); ok {
+		c.JSON(http.StatusOK, gin.H{"citizen_id": kyc.CitizenID, "status": kyc.Status})
+	}
+}
+
+// RevokeKYC revokes a KYC record, e.g. of a sanctioned wallet, and queues the
+// burn of its NFTs. A reason is required. The record stays revoking until the
+// burns are confirmed; revoking it again retries them.
+func (h *Handler) RevokeKYC(c *gin.Context) {
+	citizenID := c.Param("citizenID")
+	kyc, err := h.repo.GetKYCByCitizenID(c.Request.Context(), citizenID)
+	if err != nil || kyc.Status != sqlc.KycStatusRevoking {
+		var ok bool
+		if kyc, ok = h.changeStatus(c, sqlc.KycStatusRevoking, true); !ok {
+			return
+		}
+	}
+
+	if err := h.producer.PublishStruct("kyc.revoke", RevokeMessage{CitizenID: kyc.CitizenID}); err != nil {
+		log.Printf("Failed to queue revoke (request %s): %v", requestID(c), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "KYC revoked but the burn could not be queued, revoke it again to retry"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"citizen_id": kyc.CitizenID, "status": kyc.Status})
+}
+
+// changeStatus moves the KYC record of the citizenID path parameter to status
+// on behalf of the staff member. Except for the status change response, it
+// writes the response itself and returns false on failure.
+func (h *Handler) changeStatus(c *gin.Context, status string, reasonRequired bool) (*sqlc.KycInfo, bool) {
+	citizenID := c.Param("citizenID")
+	var req StatusChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return nil, false
+	}
+	if reasonRequired && req.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required"})
+		return nil, false
+	}
+
+	actor := staffPrincipal(c)
+	kyc, err := h.repo.TransitionKYC(c.Request.Context(), citizenID, status, req.Reason)
+	var invalid *sqlc.InvalidKycTransitionError
+	switch {
+	case errors.As(err, &invalid):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": invalid.From})
+		return nil, false
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "KYC not found"})
+		return nil, false
+	case err != nil:
+		log.Printf("Failed to change KYC status to %s (request %s): %v", status, requestID(c), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change KYC status"})
+		return nil, false
+	}
+	log.Printf("KYC moved to %s by %s (request %s)", status, actor.Name, requestID(c))
+	return kyc, true
+}
+
+// RetryMint queues the mint of an approved KYC record, e.g. after a failed
+// mint transaction moved it back to approved.
+func (h *Handler) RetryMint(c *gin.Context) {
+	citizenID := c.Param("citizenID")
+	kyc, err := h.repo.GetKYCByCitizenID(c.Request.Context(), citizenID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "KYC not found"})
+		return
+	}
+	if kyc.Status != sqlc.KycStatusApproved {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "Only approved KYC records can be minted",
+			"status": kyc.Status,
+		})
+		return
+	}
+
+	wallets, err := h.publishMint(c, *kyc)
+	if err != nil {
+		log.Printf("Failed to queue mint (request %s): %v", requestID(c), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue mint"})
+		return
+	}
+	log.Printf("Mint queued by %s (request %s)", staffPrincipal(c).Name, requestID(c))
+	c.JSON(http.StatusAccepted, gin.H{"citizen_id": citizenID, "mint_queued_for": wallets})
+}
+
+// publishMint queues a mint for every wallet bound to the KYC record.
+func (h *Handler) publishMint(c *gin.Context, kyc sqlc.KycInfo) ([]string, error) {
+	wallets, err := h.repo.GetWalletsByCitizenID(c.Request.Context(), kyc.CitizenID)
+	if err != nil {
+		return nil, err
+	}
+	for _, wallet := range wallets {
+		mintMsg := MintMessage{
+			CitizenID:     kyc.CitizenID,
+			WalletAddress: wallet,
+		}
+		if err := h.producer.PublishStruct("kyc.mint", mintMsg); err != nil {
+			return nil, err
+		}
+	}
+	return wallets, nil
+}
+
+type KYCEventsQueryParams struct {
+	Limit int32 `form:"limit" binding:"omitempty,min=1,max=1000"`
+	// Before is the id of the last event of the previous page
+	Before int64 `form:"before" binding:"omitempty,min=1"`
+}
+
+// KYCEventResponse is an entry of the audit trail of a KYC record.
+type KYCEventResponse struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Changes   json.RawMessage `json:"changes"`
+	RequestID string          `json:"request_id"`
+	SourceIP  string          `json:"source_ip"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// GetKYCEvents returns the audit trail of a KYC record, newest first.
+func (h *Handler) GetKYCEvents(c *gin.Context) {
+	var queryParams KYCEventsQueryParams
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if queryParams.Limit == 0 {
+		queryParams.Limit = 100
+	}
+	if queryParams.Before == 0 {
+		queryParams.Before = math.MaxInt64
+	}
+
+	citizenID := c.Param("citizenID")
+	events, err := h.repo.GetKYCEvents(c.Request.Context(), citizenID, queryParams.Before, queryParams.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]KYCEventResponse, len(events))
+	for i, event := range events {
+		response[i] = KYCEventResponse{
+			ID:        event.ID,
+			Actor:     event.Actor.String,
+			Action:    event.Action,
+			Changes:   json.RawMessage(event.Changes),
+			RequestID: event.RequestID.String,
+			SourceIP:  event.SourceIp.String,
+			CreatedAt: event.CreatedAt.Time,
+		}
+	}
+	result := gin.H{
+		"citizen_id": citizenID,
+		"events":     response,
+		"count":      len(response),
+	}
+	if len(events) == int(queryParams.Limit) {
+		result["next_before"] = events[len(events)-1].ID
+	}
+	c.JSON(http.StatusOK, result)
+}
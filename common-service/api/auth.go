@@ -0,0 +1,207 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"common-service/auth"
+	"common-service/sigverify"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/yourrepo/db/sqlc"
+)
+
+// SiweNonceValidity is how long a login nonce can be used
+const SiweNonceValidity = 10 * time.Minute
+
+// Gin context keys set by the authentication middlewares
+const (
+	sessionWalletKey = "session_wallet"
+	principalKey     = "principal"
+)
+
+// Audit records the request ID and source IP of every request in its context,
+// so changes made by the request can be traced in the KYC audit trail. The
+// request ID is taken from the X-Request-ID header or generated.
+func (h *Handler) Audit(c *gin.Context) {
+	requestID := c.GetHeader("X-Request-ID")
+	if requestID == "" || len(requestID) > 100 {
+		buf := make([]byte, 8)
+		rand.Read(buf)
+		requestID = hex.EncodeToString(buf)
+	}
+	c.Header("X-Request-ID", requestID)
+	c.Request = c.Request.WithContext(sqlc.WithAudit(c.Request.Context(), sqlc.Audit{
+		RequestID: requestID,
+		SourceIP:  c.ClientIP(),
+	}))
+	c.Next()
+}
+
+// requestID returns the ID the Audit middleware assigned to the request, which
+// is logged instead of personal data to correlate log lines.
+func requestID(c *gin.Context) string {
+	return sqlc.AuditFromContext(c.Request.Context()).RequestID
+}
+
+// GetNonce issues a single-use nonce for a Sign-In-With-Ethereum message.
+func (h *Handler) GetNonce(c *gin.Context) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate nonce"})
+		return
+	}
+	nonce := hex.EncodeToString(buf)
+	expiresAt := time.Now().Add(SiweNonceValidity)
+
+	if err := h.repo.CreateSiweNonce(c.Request.Context(), nonce, expiresAt); err != nil {
+		log.Printf("Failed to store login nonce: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate nonce"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"nonce":      nonce,
+		"domain":     h.sessions.Domain(),
+		"expires_at": expiresAt.Unix(),
+	})
+}
+
+type LoginRequest struct {
+	// Message is the EIP-4361 message signed with personal_sign
+	Message   string `json:"message" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
+
+// Login verifies a signed Sign-In-With-Ethereum message and returns a session
+// token for its wallet.
+func (h *Handler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	msg, err := auth.ParseSiweMessage(req.Message)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid SIWE message: " + err.Error()})
+		return
+	}
+	if err := h.sessions.ValidateLogin(msg); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid SIWE message: " + err.Error()})
+		return
+	}
+
+	signer, err := sigverify.RecoverPersonalSign(req.Message, req.Signature)
+	if err != nil || signer != msg.Address {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+		return
+	}
+
+	// Burn the nonce last so a bad signature cannot be used to invalidate it
+	fresh, err := h.repo.UseSiweNonce(c.Request.Context(), msg.Nonce)
+	if err != nil {
+		log.Printf("Failed to use login nonce: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		return
+	}
+	if !fresh {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unknown, expired or already used nonce"})
+		return
+	}
+
+	token, expiresAt, err := h.sessions.Issue(auth.Claims{Subject: msg.Address.Hex()})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"token":          token,
+		"wallet_address": msg.Address.Hex(),
+		"expires_at":     expiresAt.Unix(),
+	})
+}
+
+// RequireSession rejects requests without a valid "Authorization: Bearer"
+// session token and stores the session wallet in the context.
+func (h *Handler) RequireSession(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing session token"})
+		return
+	}
+	claims, err := h.sessions.Parse(token)
+	if err != nil || claims.Role != "" || !common.IsHexAddress(claims.Subject) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid session token"})
+		return
+	}
+	wallet := common.HexToAddress(claims.Subject)
+	c.Set(sessionWalletKey, wallet)
+	c.Request = c.Request.WithContext(sqlc.WithAuditActor(c.Request.Context(), wallet.Hex()))
+	c.Next()
+}
+
+// sessionWallet returns the wallet set by RequireSession.
+func sessionWallet(c *gin.Context) common.Address {
+	wallet, _ := c.Get(sessionWalletKey)
+	address, _ := wallet.(common.Address)
+	return address
+}
+
+// RequireRole rejects requests that are not authenticated as staff with one of
+// the given roles, either by an "X-API-Key" header or by a bearer token with a
+// role claim.
+func (h *Handler) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var principal auth.Principal
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			p, ok := h.apiKeys.Lookup(key)
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+				return
+			}
+			principal = p
+		} else if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+			claims, err := h.sessions.Parse(token)
+			if err != nil || claims.Role == "" {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid staff token"})
+				return
+			}
+			principal = auth.Principal{Name: claims.Subject, Role: claims.Role}
+		} else {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing API key or staff token"})
+			return
+		}
+
+		if !principal.HasRole(roles...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient role"})
+			return
+		}
+		c.Set(principalKey, principal)
+		c.Request = c.Request.WithContext(sqlc.WithAuditActor(c.Request.Context(), principal.Name))
+		c.Next()
+	}
+}
+
+// staffPrincipal returns the principal set by RequireRole.
+func staffPrincipal(c *gin.Context) auth.Principal {
+	principal, _ := c.Get(principalKey)
+	p, _ := principal.(auth.Principal)
+	return p
+}
+
+// requireOwnCitizen checks that the citizen ID is bound to the session wallet.
+// It writes the error response and returns false otherwise.
+func (h *Handler) requireOwnCitizen(c *gin.Context, citizenID string) bool {
+	kyc, err := h.repo.GetKYCByWalletAddress(c.Request.Context(), sessionWallet(c).Hex())
+	if err != nil || kyc.CitizenID != citizenID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "KYC record does not belong to this wallet"})
+		return false
+	}
+	return true
+}
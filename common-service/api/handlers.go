@@ -1,12 +1,19 @@
 package api
 
 import (
+	"errors"
 	"log"
+	"math/big"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
+	"common-service/auth"
+	"common-service/merkle"
+	"common-service/screening"
+	"common-service/sigverify"
+
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/yourusername/yourrepo/db/sqlc"
@@ -15,39 +22,67 @@ import (
 
 // Handler struct holds dependencies for API handlers
 type Handler struct {
-	repo     *sqlc.Repository
-	producer *rabbitmq.Producer
+	repo       *sqlc.Repository
+	producer   *rabbitmq.Producer
+	trees      *merkle.Store
+	signatures *sigverify.Verifier
+	sessions   *auth.Sessions
+	apiKeys    auth.APIKeys
+	maxWallets int
+	screening  *screening.Service
 }
 
 // NewHandler creates a new Handler instance
-func NewHandler(repo *sqlc.Repository, producer *rabbitmq.Producer) *Handler {
+func NewHandler(repo *sqlc.Repository, producer *rabbitmq.Producer, trees *merkle.Store, signatures *sigverify.Verifier, sessions *auth.Sessions, apiKeys auth.APIKeys, maxWallets int, screeningService *screening.Service) *Handler {
 	return &Handler{
-		repo:     repo,
-		producer: producer,
+		repo:       repo,
+		producer:   producer,
+		trees:      trees,
+		signatures: signatures,
+		sessions:   sessions,
+		apiKeys:    apiKeys,
+		maxWallets: maxWallets,
+		screening:  screeningService,
 	}
 }
 
 // KYCRequest represents the request payload for KYC submission
 type KYCRequest struct {
-	CitizenID       string `json:"citizen_id"`
-	FullName        string `json:"full_name"`
-	PhoneNumber     string `json:"phone_number"`
-	DateOfBirth     string `json:"date_of_birth"` // Format: YYYY-MM-DD
-	Nationality     string `json:"nationality"`
-	Verifier        string `json:"verifier,omitempty"`
-	IsActive        bool   `json:"is_active"`
-	KYCVerifiedAt   string `json:"kyc_verified_at,omitempty"` // Format: YYYY-MM-DD HH:MM:SS or empty
+	CitizenID   string `json:"citizen_id"`
+	FullName    string `json:"full_name"`
+	PhoneNumber string `json:"phone_number"`
+	DateOfBirth string `json:"date_of_birth"` // Format: YYYY-MM-DD
+	Nationality string `json:"nationality"`
+	WalletBinding
+}
+
+// WalletBinding is a wallet's signature over its binding to a citizen ID.
+type WalletBinding struct {
 	WalletAddress   string `json:"wallet_address"`
 	WalletSignature string `json:"wallet_signature"`
+	// Nonce and SignatureExpiresAt (unix seconds) are part of the signed binding message
+	Nonce              string `json:"nonce"`
+	SignatureExpiresAt int64  `json:"signature_expires_at"`
+	// SignatureType is "personal_sign" (default) or "eip712"
+	SignatureType string `json:"signature_type,omitempty"`
 }
 
-// MintMessage represents the message structure for minting NFT
+// MintMessage represents the message structure for minting NFT. It carries
+// no personal data beyond the citizen ID the record is looked up by.
 type MintMessage struct {
-	sqlc.KycInfo  `json:"kyc"`
+	CitizenID     string `json:"citizen_id"`
 	WalletAddress string `json:"wallet_address"`
 }
 
-// SubmitKYC handles the submission of KYC information.
+// RevokeMessage asks the revoke worker to burn the NFTs of a revoked record,
+// or only the NFT of WalletAddress after it was unlinked.
+type RevokeMessage struct {
+	CitizenID     string `json:"citizen_id"`
+	WalletAddress string `json:"wallet_address,omitempty"`
+}
+
+// SubmitKYC handles the submission of KYC information for the session wallet.
+// The submission waits for a verifier to review it before anything is minted.
 func (h *Handler) SubmitKYC(c *gin.Context) {
 	var req KYCRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -62,26 +97,27 @@ func (h *Handler) SubmitKYC(c *gin.Context) {
 		return
 	}
 
-	// Debug log: Print request data
-	log.Printf("KYC Request Data: CitizenID=%s, FullName=%s, PhoneNumber=%s, DateOfBirth=%s, Nationality=%s, Verifier=%s, WalletAddress=%s",
-		req.CitizenID, req.FullName, req.PhoneNumber, req.DateOfBirth, req.Nationality, req.Verifier, req.WalletAddress)
+	// Users can only submit data for the wallet they are logged in with
+	if !common.IsHexAddress(req.WalletAddress) || common.HexToAddress(req.WalletAddress) != sessionWallet(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "wallet_address must be the wallet of the session"})
+		return
+	}
+
+	// The wallet must have signed the binding to this citizen ID
+	if !h.verifyWalletBinding(c, req.CitizenID, req.WalletBinding) {
+		return
+	}
+	if !h.screenWallet(c, req.WalletAddress) {
+		return
+	}
 
 	// Check if KYC already exists for this wallet
 	existingKYC, err := h.repo.GetKYCByWalletAddress(c.Request.Context(), req.WalletAddress)
 	if err == nil && existingKYC != nil {
-		if existingKYC.IsActive.Bool {
-			c.JSON(http.StatusConflict, gin.H{"error": "KYC is already active for this wallet"})
-			return
-		}
-		mintMsg := MintMessage{
-			KycInfo:       *existingKYC,
-			WalletAddress: req.WalletAddress,
-		}
-		if err := h.producer.PublishStruct("kyc.mint", mintMsg); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process KYC submission"})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{"message": "KYC exists but not active, proceeding with mint"})
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "KYC has already been submitted for this wallet",
+			"status": existingKYC.Status,
+		})
 		return
 	}
 
@@ -92,45 +128,24 @@ func (h *Handler) SubmitKYC(c *gin.Context) {
 		return
 	}
 
-	// Parse KYC verified at if provided
-	var kycVerifiedAt pgtype.Timestamp
-	if req.KYCVerifiedAt != "" {
-		verifiedAt, err := time.Parse("2006-01-02 15:04:05", req.KYCVerifiedAt)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kyc_verified_at format. Use YYYY-MM-DD HH:MM:SS"})
-			return
-		}
-		kycVerifiedAt = pgtype.Timestamp{Time: verifiedAt, Valid: true}
-	} else {
-		kycVerifiedAt = pgtype.Timestamp{Time: time.Now(), Valid: true}
-	}
-
+	// The verifier and verification time are set when the submission is approved
 	kyc := sqlc.KycInfo{
 		CitizenID:     req.CitizenID,
 		FullName:      pgtype.Text{String: req.FullName, Valid: true},
 		PhoneNumber:   pgtype.Text{String: req.PhoneNumber, Valid: true},
 		DateOfBirth:   pgtype.Date{Time: dob, Valid: true},
 		Nationality:   pgtype.Text{String: req.Nationality, Valid: true},
-		Verifier:      pgtype.Text{String: req.Verifier, Valid: req.Verifier != ""},
-		IsActive:      pgtype.Bool{Bool: false, Valid: true}, // Khi tạo mới luôn là false
-		KycVerifiedAt: kycVerifiedAt,
+		KycVerifiedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
 	}
 
-	// Debug log: Print created KYC struct
-	log.Printf("Created KYC Struct: CitizenID=%s, FullName={String:%s, Valid:%t}, PhoneNumber={String:%s, Valid:%t}, Nationality={String:%s, Valid:%t}",
-		kyc.CitizenID, kyc.FullName.String, kyc.FullName.Valid, kyc.PhoneNumber.String, kyc.PhoneNumber.Valid, kyc.Nationality.String, kyc.Nationality.Valid)
-
-	if err := h.repo.SubmitKYC(c.Request.Context(), kyc, req.WalletAddress, req.WalletSignature); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save KYC information"})
+	err = h.repo.SubmitKYC(c.Request.Context(), kyc, req.WalletAddress, req.WalletSignature)
+	if errors.Is(err, sqlc.ErrWalletLinked) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
-
-	mintMsg := MintMessage{
-		KycInfo:       kyc,
-		WalletAddress: req.WalletAddress,
-	}
-	if err := h.producer.PublishStruct("kyc.mint", mintMsg); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process KYC submission"})
+	if err != nil {
+		log.Printf("Failed to save KYC of wallet %s: %v", req.WalletAddress, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save KYC information"})
 		return
 	}
 
@@ -139,14 +154,88 @@ func (h *Handler) SubmitKYC(c *gin.Context) {
 		"data": gin.H{
 			"citizen_id":     kyc.CitizenID,
 			"wallet_address": req.WalletAddress,
-			"is_active":      kyc.IsActive.Bool,
+			"status":         sqlc.KycStatusSubmitted,
 		},
 	})
 }
 
-// GetKYCByCitizenID retrieves KYC information by citizen ID.
+// verifyWalletBinding checks the signature of a wallet over its binding to a
+// citizen ID and burns its nonce. It writes the error response and returns
+// false when the request must be rejected.
+func (h *Handler) verifyWalletBinding(c *gin.Context, citizenID string, req WalletBinding) bool {
+	if !common.IsHexAddress(req.WalletAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet_address"})
+		return false
+	}
+	if req.WalletSignature == "" || req.Nonce == "" || req.SignatureExpiresAt == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "wallet_signature, nonce and signature_expires_at are required"})
+		return false
+	}
+
+	binding := sigverify.Binding{
+		WalletAddress: common.HexToAddress(req.WalletAddress),
+		CitizenID:     citizenID,
+		Nonce:         req.Nonce,
+		ExpiresAt:     time.Unix(req.SignatureExpiresAt, 0),
+	}
+	if err := h.signatures.VerifyBinding(binding, req.WalletSignature, req.SignatureType); err != nil {
+		log.Printf("Rejected wallet signature for %s: %v", req.WalletAddress, err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid wallet signature: " + err.Error()})
+		return false
+	}
+
+	fresh, err := h.repo.UseSignatureNonce(c.Request.Context(), binding.WalletAddress.Hex(), binding.Nonce, binding.ExpiresAt)
+	if err != nil {
+		log.Printf("Failed to record signature nonce: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify wallet signature"})
+		return false
+	}
+	if !fresh {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Signature nonce has already been used"})
+		return false
+	}
+	return true
+}
+
+type BindingMessageQueryParams struct {
+	WalletAddress string `form:"wallet_address" binding:"required"`
+	CitizenID     string `form:"citizen_id" binding:"required"`
+	Nonce         string `form:"nonce" binding:"required"`
+	ExpiresAt     int64  `form:"expires_at" binding:"required"`
+}
+
+// GetBindingMessage returns the personal_sign message and the EIP-712 typed
+// data a wallet has to sign to be bound to a citizen ID.
+func (h *Handler) GetBindingMessage(c *gin.Context) {
+	var queryParams BindingMessageQueryParams
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !common.IsHexAddress(queryParams.WalletAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet_address"})
+		return
+	}
+
+	binding := sigverify.Binding{
+		WalletAddress: common.HexToAddress(queryParams.WalletAddress),
+		CitizenID:     queryParams.CitizenID,
+		Nonce:         queryParams.Nonce,
+		ExpiresAt:     time.Unix(queryParams.ExpiresAt, 0),
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":    binding.Message(),
+		"typed_data": h.signatures.TypedData(binding),
+	})
+}
+
+// GetKYCByCitizenID retrieves KYC information by citizen ID. Only the wallet
+// bound to the citizen ID can read it.
 func (h *Handler) GetKYCByCitizenID(c *gin.Context) {
 	citizenID := c.Param("citizenID")
+	if !h.requireOwnCitizen(c, citizenID) {
+		return
+	}
 	kyc, err := h.repo.GetKYCByCitizenID(c.Request.Context(), citizenID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -155,9 +244,13 @@ func (h *Handler) GetKYCByCitizenID(c *gin.Context) {
 	c.JSON(http.StatusOK, kyc)
 }
 
-// GetKYCByWalletAddress retrieves KYC information by wallet address.
+// GetKYCByWalletAddress retrieves KYC information of the session wallet.
 func (h *Handler) GetKYCByWalletAddress(c *gin.Context) {
 	walletAddress := c.Param("walletAddress")
+	if !common.IsHexAddress(walletAddress) || common.HexToAddress(walletAddress) != sessionWallet(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "KYC record does not belong to this wallet"})
+		return
+	}
 	kyc, err := h.repo.GetKYCByWalletAddress(c.Request.Context(), walletAddress)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -166,13 +259,18 @@ func (h *Handler) GetKYCByWalletAddress(c *gin.Context) {
 	c.JSON(http.StatusOK, kyc)
 }
 
-// UpdateKYC updates KYC information.
+// UpdateKYC updates the personal data of the KYC record bound to the session
+// wallet and sends it back to review. Records cannot be changed once approved
+// until they expire, after which an update starts their re-verification.
 func (h *Handler) UpdateKYC(c *gin.Context) {
 	var req KYCRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
 		return
 	}
+	if !h.requireOwnCitizen(c, req.CitizenID) {
+		return
+	}
 
 	dob, err := time.Parse("2006-01-02", req.DateOfBirth)
 	if err != nil {
@@ -180,38 +278,31 @@ func (h *Handler) UpdateKYC(c *gin.Context) {
 		return
 	}
 
-	var kycVerifiedAt pgtype.Timestamp
-	if req.KYCVerifiedAt != "" {
-		verifiedAt, err := time.Parse("2006-01-02 15:04:05", req.KYCVerifiedAt)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kyc_verified_at format. Use YYYY-MM-DD HH:MM:SS"})
-			return
-		}
-		kycVerifiedAt = pgtype.Timestamp{Time: verifiedAt, Valid: true}
-	} else {
-		kycVerifiedAt = pgtype.Timestamp{Time: time.Now(), Valid: true}
-	}
-
 	kyc := sqlc.KycInfo{
-		CitizenID:     req.CitizenID,
-		FullName:      pgtype.Text{String: req.FullName, Valid: true},
-		PhoneNumber:   pgtype.Text{String: req.PhoneNumber, Valid: true},
-		DateOfBirth:   pgtype.Date{Time: dob, Valid: true},
-		Nationality:   pgtype.Text{String: req.Nationality, Valid: true},
-		Verifier:      pgtype.Text{String: req.Verifier, Valid: req.Verifier != ""},
-		IsActive:      pgtype.Bool{Bool: req.IsActive, Valid: true},
-		KycVerifiedAt: kycVerifiedAt,
+		CitizenID:   req.CitizenID,
+		FullName:    pgtype.Text{String: req.FullName, Valid: true},
+		PhoneNumber: pgtype.Text{String: req.PhoneNumber, Valid: true},
+		DateOfBirth: pgtype.Date{Time: dob, Valid: true},
+		Nationality: pgtype.Text{String: req.Nationality, Valid: true},
 	}
 
-	if err := h.repo.UpdateKYC(c.Request.Context(), kyc); err != nil {
+	updated, err := h.repo.UpdateKYCPersonalInfo(c.Request.Context(), kyc)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update KYC information"})
 		return
 	}
+	if !updated {
+		c.JSON(http.StatusConflict, gin.H{"error": "KYC records cannot be changed once approved, until they expire"})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "KYC updated successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "KYC updated successfully", "status": sqlc.KycStatusSubmitted})
 }
 
-// CheckKYCStatusByWalletAddress checks if KYC is active by wallet address.
+// CheckKYCStatusByWalletAddress returns the KYC status of a wallet address,
+// when the record expires and the reason of its status, e.g. why it was
+// rejected or that it expired. is_active is kept for clients that only need
+// to know whether it is active.
 func (h *Handler) CheckKYCStatusByWalletAddress(c *gin.Context) {
 	walletAddress := c.Param("walletAddress")
 	if walletAddress == "" {
@@ -219,8 +310,8 @@ func (h *Handler) CheckKYCStatusByWalletAddress(c *gin.Context) {
 		return
 	}
 
-	isActive, err := h.repo.GetKYCStatusByWalletAddress(c.Request.Context(), walletAddress)
-	if err != nil || !isActive.Valid {
+	status, err := h.repo.GetKYCStatusByWalletAddress(c.Request.Context(), walletAddress)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":          "KYC not found",
 			"wallet_address": walletAddress,
@@ -229,9 +320,21 @@ func (h *Handler) CheckKYCStatusByWalletAddress(c *gin.Context) {
 		return
 	}
 
+	// expires_at and reason are null until an expiry or a reason is known
+	var expiresAt *time.Time
+	if status.ExpiresAt.Valid {
+		expiresAt = &status.ExpiresAt.Time
+	}
+	var reason *string
+	if status.StatusReason.Valid {
+		reason = &status.StatusReason.String
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"wallet_address": walletAddress,
-		"is_active":      isActive.Bool,
+		"status":         status.Status,
+		"is_active":      status.Status == sqlc.KycStatusActive,
+		"expires_at":     expiresAt,
+		"reason":         reason,
 	})
 }
 
@@ -244,10 +347,93 @@ type EventUriParams struct {
 type EventQueryParams struct {
 	FromBlock string `form:"fromBlock" binding:"required"`
 	ToBlock   string `form:"toBlock" binding:"required"`
-	Limit     string `form:"limit" binding:"required"`
+	// Limit is the page size, DefaultEventPageSize when empty and capped at MaxEventPageSize
+	Limit    string `form:"limit"`
+	Finality string `form:"finality" binding:"omitempty,oneof=confirmed latest"`
+	Order    string `form:"order" binding:"omitempty,oneof=asc desc"`
+	// Cursor is the next_cursor of the previous page
+	Cursor string `form:"cursor"`
+}
+
+// Finality values accepted by the event, leaf, proof and root endpoints
+const (
+	FinalityConfirmed = "confirmed" // only events past the listener's confirmation depth
+	FinalityLatest    = "latest"    // every indexed event, including pending ones
+)
+
+// DepositEventResponse is a deposit as returned by the event endpoints.
+type DepositEventResponse struct {
+	ChainID         int32  `json:"chain_id"`
+	ContractAddress string `json:"contract_address"`
+	Commitment      string `json:"commitment"`
+	Depositor       string `json:"depositor"`
+	LeafIndex       int32  `json:"leaf_index"`
+	Timestamp       string `json:"timestamp"`
+	TxHash          string `json:"tx_hash"`
+	BlockNumber     int32  `json:"block_number"`
+	LogIndex        int32  `json:"log_index"`
+	Confirmed       bool   `json:"confirmed"`
+}
+
+// WithdrawalEventResponse is a withdrawal as returned by the event endpoints.
+// Clients match NullifierHash against their notes to find the spent ones.
+type WithdrawalEventResponse struct {
+	ChainID         int32  `json:"chain_id"`
+	ContractAddress string `json:"contract_address"`
+	NullifierHash   string `json:"nullifier_hash"`
+	Recipient       string `json:"recipient"`
+	Relayer         string `json:"relayer"`
+	Fee             string `json:"fee"`
+	TxHash          string `json:"tx_hash"`
+	BlockNumber     int32  `json:"block_number"`
+	LogIndex        int32  `json:"log_index"`
+	Confirmed       bool   `json:"confirmed"`
+}
+
+func newDepositEventResponse(deposit sqlc.Deposit) DepositEventResponse {
+	return DepositEventResponse{
+		ChainID:         deposit.ChainID.Int32,
+		ContractAddress: deposit.ContractAddress.String,
+		Commitment:      deposit.Commitment.String,
+		Depositor:       deposit.Depositor.String,
+		LeafIndex:       deposit.LeafIndex.Int32,
+		Timestamp:       numericString(deposit.Timestamp),
+		TxHash:          deposit.TxHash.String,
+		BlockNumber:     deposit.BlockNumber.Int32,
+		LogIndex:        deposit.LogIndex.Int32,
+		Confirmed:       deposit.Confirmed,
+	}
+}
+
+func newWithdrawalEventResponse(withdrawal sqlc.Withdrawal) WithdrawalEventResponse {
+	return WithdrawalEventResponse{
+		ChainID:         withdrawal.ChainID.Int32,
+		ContractAddress: withdrawal.ContractAddress.String,
+		NullifierHash:   withdrawal.NullifierHash.String,
+		Recipient:       withdrawal.Recipient.String,
+		Relayer:         withdrawal.Relayer.String,
+		Fee:             numericString(withdrawal.Fee),
+		TxHash:          withdrawal.TxHash.String,
+		BlockNumber:     withdrawal.BlockNumber.Int32,
+		LogIndex:        withdrawal.LogIndex.Int32,
+		Confirmed:       withdrawal.Confirmed,
+	}
+}
+
+// numericString formats an integer NUMERIC column in base 10.
+func numericString(n pgtype.Numeric) string {
+	if !n.Valid || n.Int == nil {
+		return ""
+	}
+	v := new(big.Int).Set(n.Int)
+	if n.Exp > 0 {
+		v.Mul(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n.Exp)), nil))
+	}
+	return v.String()
 }
 
-// GetEvents handles retrieving events from a specific block
+// GetEvents handles retrieving events from a specific block. Results are paged
+// by (block_number, log_index); next_cursor is empty on the last page.
 func (h *Handler) GetEvents(c *gin.Context) {
 	var uriParams EventUriParams
 	var queryParams EventQueryParams
@@ -262,44 +448,84 @@ func (h *Handler) GetEvents(c *gin.Context) {
 		return
 	}
 
-	var events []sqlc.Deposit
-	var err error
-
-	if strings.ToLower(uriParams.EventType) == "withdrawal" {
-		// TODO: Implement withdrawal event retrieval
-	} else if strings.ToLower(uriParams.EventType) == "deposit" {
-		events, err = h.repo.GetDepositEventsFromBlockToBlock(c.Request.Context(), uriParams.NetId, uriParams.ContractAddress, queryParams.FromBlock, queryParams.ToBlock)
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid event type",
-		})
-		return
+	order := queryParams.Order
+	if order == "" {
+		order = OrderDesc
 	}
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch events",
-		})
-		return
+	limit := pageSize(queryParams.Limit)
+	page := sqlc.EventPage{
+		Descending: order == OrderDesc,
+		// One extra row tells whether another page follows
+		Limit: int32(limit + 1),
 	}
-	// Check if we need to limit the number of events returned
-	limitInt, err := strconv.Atoi(queryParams.Limit)
-	if err != nil {
-		limitInt = 0 // Default to 0 (no limit) if conversion fails
+	if queryParams.Cursor != "" {
+		cursor, err := decodeCursor(queryParams.Cursor, order)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		page.After = cursor
 	}
+	confirmedOnly := queryParams.Finality == FinalityConfirmed
 
-	var responseEvents []sqlc.Deposit
-	if limitInt > 0 && limitInt < len(events) {
-		responseEvents = events[:limitInt]
-	} else {
-		responseEvents = events
-	}
+	switch strings.ToLower(uriParams.EventType) {
+	case "withdrawal":
+		withdrawals, err := h.repo.GetWithdrawalEventsFromBlockToBlock(c.Request.Context(), uriParams.NetId, uriParams.ContractAddress, queryParams.FromBlock, queryParams.ToBlock, confirmedOnly, page)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch events",
+			})
+			return
+		}
 
-	c.JSON(http.StatusOK, gin.H{
-		"events": responseEvents,
-		"count":  len(responseEvents),
-	})
+		nextCursor := ""
+		if len(withdrawals) > limit {
+			withdrawals = withdrawals[:limit]
+			last := withdrawals[limit-1]
+			nextCursor = encodeCursor(order, last.BlockNumber, last.LogIndex)
+		}
 
+		responseEvents := make([]WithdrawalEventResponse, len(withdrawals))
+		for i, withdrawal := range withdrawals {
+			responseEvents[i] = newWithdrawalEventResponse(withdrawal)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"event_type":  "withdrawal",
+			"events":      responseEvents,
+			"count":       len(responseEvents),
+			"next_cursor": nextCursor,
+		})
+	case "deposit":
+		deposits, err := h.repo.GetDepositEventsFromBlockToBlock(c.Request.Context(), uriParams.NetId, uriParams.ContractAddress, queryParams.FromBlock, queryParams.ToBlock, confirmedOnly, page)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch events",
+			})
+			return
+		}
+
+		nextCursor := ""
+		if len(deposits) > limit {
+			deposits = deposits[:limit]
+			last := deposits[limit-1]
+			nextCursor = encodeCursor(order, last.BlockNumber, last.LogIndex)
+		}
+
+		responseEvents := make([]DepositEventResponse, len(deposits))
+		for i, deposit := range deposits {
+			responseEvents[i] = newDepositEventResponse(deposit)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"event_type":  "deposit",
+			"events":      responseEvents,
+			"count":       len(responseEvents),
+			"next_cursor": nextCursor,
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid event type",
+		})
+	}
 }
 
 type GetEventByInfoUriParams struct {
@@ -347,14 +573,24 @@ type GetLeavesUriParams struct {
 	ContractAddress string `uri:"contractAddress" binding:"required"`
 }
 
+type GetLeavesQueryParams struct {
+	Finality string `form:"finality" binding:"omitempty,oneof=confirmed latest"`
+}
+
 func (h *Handler) GetLeaves(c *gin.Context) {
 	var uriParams GetLeavesUriParams
+	var queryParams GetLeavesQueryParams
 	if err := c.ShouldBindUri(&uriParams); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	leaves, err := h.repo.GetLeaves(c.Request.Context(), uriParams.NetId, uriParams.ContractAddress)
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	leaves, err := h.repo.GetLeaves(c.Request.Context(), uriParams.NetId, uriParams.ContractAddress, queryParams.Finality == FinalityConfirmed)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -364,3 +600,110 @@ func (h *Handler) GetLeaves(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"leaves": leaves})
 }
+
+type GetProofUriParams struct {
+	NetId           string `uri:"netId" binding:"required,numeric"`
+	ContractAddress string `uri:"contractAddress" binding:"required"`
+	Commitment      string `uri:"commitment" binding:"required"`
+}
+
+type GetProofQueryParams struct {
+	Finality string `form:"finality" binding:"omitempty,oneof=confirmed latest"`
+}
+
+// GetProof returns the Merkle path of a commitment in the contract's deposit
+// tree, so clients no longer have to rebuild the tree from every leaf. With
+// finality=confirmed the proof is made against the tree of confirmed
+// deposits, whose root a reorg cannot remove.
+func (h *Handler) GetProof(c *gin.Context) {
+	var uriParams GetProofUriParams
+	var queryParams GetProofQueryParams
+	if err := c.ShouldBindUri(&uriParams); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !common.IsHexAddress(uriParams.ContractAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contract address"})
+		return
+	}
+	// The listener stores checksummed addresses
+	contractAddress := common.HexToAddress(uriParams.ContractAddress).Hex()
+
+	proof, leafIndex, err := h.trees.Proof(c.Request.Context(), uriParams.NetId, contractAddress, uriParams.Commitment, queryParams.Finality == FinalityConfirmed)
+	if errors.Is(err, merkle.ErrInvalidCommitment) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid commitment"})
+		return
+	}
+	if errors.Is(err, merkle.ErrLeafNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Commitment not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to build merkle proof: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build merkle proof"})
+		return
+	}
+
+	pathElements := make([]string, len(proof.PathElements))
+	for i, element := range proof.PathElements {
+		pathElements[i] = merkle.FormatNode(element)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"commitment":    strings.ToLower(uriParams.Commitment),
+		"leaf_index":    leafIndex,
+		"root":          merkle.FormatNode(proof.Root),
+		"path_elements": pathElements,
+		"path_indices":  proof.PathIndices,
+	})
+}
+
+type GetRootsUriParams struct {
+	NetId           string `uri:"netId" binding:"required,numeric"`
+	ContractAddress string `uri:"contractAddress" binding:"required"`
+}
+
+type GetRootsQueryParams struct {
+	Limit    int32  `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Finality string `form:"finality" binding:"omitempty,oneof=confirmed latest"`
+}
+
+// GetRoots returns the recent roots of a contract's deposit tree with their age
+// in deposits, plus the outcome of the last on-chain root check. With
+// finality=confirmed only the roots of confirmed deposits are returned.
+func (h *Handler) GetRoots(c *gin.Context) {
+	var uriParams GetRootsUriParams
+	var queryParams GetRootsQueryParams
+	if err := c.ShouldBindUri(&uriParams); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !common.IsHexAddress(uriParams.ContractAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contract address"})
+		return
+	}
+	contractAddress := common.HexToAddress(uriParams.ContractAddress).Hex()
+	if queryParams.Limit == 0 {
+		queryParams.Limit = merkle.RootHistorySize
+	}
+
+	roots, err := h.trees.Roots(c.Request.Context(), uriParams.NetId, contractAddress, queryParams.Limit, queryParams.Finality == FinalityConfirmed)
+	if err != nil {
+		log.Printf("Failed to fetch merkle roots: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch merkle roots"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"roots":             roots,
+		"count":             len(roots),
+		"root_history_size": merkle.RootHistorySize,
+		"last_check":        h.trees.LastCheck(uriParams.NetId, contractAddress),
+	})
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/yourusername/yourrepo/db/sqlc"
+)
+
+// Page sizes of the event endpoints
+const (
+	DefaultEventPageSize = 100
+	MaxEventPageSize     = 1000
+)
+
+// Sort orders accepted by the event endpoints
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// encodeCursor turns the position of the last returned event into an opaque
+// token. The order is part of the token so it cannot be replayed the other way.
+func encodeCursor(order string, blockNumber pgtype.Int4, logIndex pgtype.Int4) string {
+	position := int32(-1)
+	if logIndex.Valid {
+		position = logIndex.Int32
+	}
+	raw := fmt.Sprintf("%s:%d:%d", order, blockNumber.Int32, position)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor parses a token produced by encodeCursor for the given order.
+func decodeCursor(token string, order string) (*sqlc.EventCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if parts[0] != order {
+		return nil, fmt.Errorf("cursor was issued for order %q", parts[0])
+	}
+	blockNumber, err := strconv.ParseInt(parts[1], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	logIndex, err := strconv.ParseInt(parts[2], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &sqlc.EventCursor{BlockNumber: int32(blockNumber), LogIndex: int32(logIndex)}, nil
+}
+
+// pageSize returns the requested page size, capped at MaxEventPageSize.
+func pageSize(limit string) int {
+	size, err := strconv.Atoi(limit)
+	if err != nil || size <= 0 {
+		return DefaultEventPageSize
+	}
+	if size > MaxEventPageSize {
+		return MaxEventPageSize
+	}
+	return size
+}
@@ -3,6 +3,8 @@ package api
 import (
 	"time"
 
+	"common-service/auth"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -15,17 +17,43 @@ func SetupRouter(h *Handler) *gin.Engine {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // Or specify your frontend URL
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Accept"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Accept", "Authorization", "X-API-Key"},
+		ExposeHeaders:    []string{"Content-Length", "X-Request-ID"},
 		AllowCredentials: false,
 		MaxAge:           12 * time.Hour,
 	}))
+	r.Use(h.Audit)
+
+	// Sign-In-With-Ethereum endpoints
+	r.GET("/auth/nonce", h.GetNonce)
+	r.POST("/auth/login", h.Login)
 
 	// KYC endpoints
-	r.POST("/kyc", h.SubmitKYC)
-	r.GET("/kyc/citizen/:citizenID", h.GetKYCByCitizenID)
-	r.GET("/kyc/wallet/:walletAddress", h.GetKYCByWalletAddress)
-	r.PUT("/kyc", h.UpdateKYC)
+	r.GET("/kyc/binding-message", h.GetBindingMessage)
+
+	// KYC records can only be submitted, read and updated by their own wallet
+	session := r.Group("/", h.RequireSession)
+	session.POST("/kyc", h.SubmitKYC)
+	session.GET("/kyc/citizen/:citizenID", h.GetKYCByCitizenID)
+	session.GET("/kyc/wallet/:walletAddress", h.GetKYCByWalletAddress)
+	session.PUT("/kyc", h.UpdateKYC)
+	session.GET("/kyc/:citizenID/wallets", h.ListWallets)
+	session.POST("/kyc/:citizenID/wallets", h.LinkWallet)
+	session.DELETE("/kyc/:citizenID/wallets/:walletAddress", h.UnlinkWallet)
+
+	// KYC review endpoints for verifiers and admins
+	admin := r.Group("/admin", h.RequireRole(auth.RoleVerifier))
+	admin.POST("/token", h.IssueStaffToken)
+	admin.GET("/kyc/pending", h.ListPendingKYC)
+	admin.GET("/kyc/:citizenID/events", h.GetKYCEvents)
+	admin.POST("/kyc/:citizenID/review", h.StartReview)
+	admin.POST("/kyc/:citizenID/approve", h.ApproveKYC)
+	admin.POST("/kyc/:citizenID/reject", h.RejectKYC)
+	admin.POST("/kyc/:citizenID/mint", h.RequireRole(auth.RoleAdmin), h.RetryMint)
+	admin.POST("/kyc/:citizenID/suspend", h.RequireRole(auth.RoleAdmin), h.SuspendKYC)
+	admin.POST("/kyc/:citizenID/reinstate", h.RequireRole(auth.RoleAdmin), h.ReinstateKYC)
+	admin.POST("/kyc/:citizenID/revoke", h.RequireRole(auth.RoleAdmin), h.RevokeKYC)
+	admin.GET("/screening/flags", h.ListFlaggedAddresses)
 
 	// KYC Status Check endpoints
 	r.GET("/kyc/status/wallet/:walletAddress", h.CheckKYCStatusByWalletAddress)
@@ -35,5 +63,9 @@ func SetupRouter(h *Handler) *gin.Engine {
 	r.GET("/event/:eventType/:hex", h.GetEventByInfo)
 	r.GET("/leaves/:netId/:contractAddress", h.GetLeaves)
 
+	// Merkle tree endpoints
+	r.GET("/proof/:netId/:contractAddress/:commitment", h.GetProof)
+	r.GET("/roots/:netId/:contractAddress", h.GetRoots)
+
 	return r
 }
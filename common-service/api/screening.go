@@ -0,0 +1,94 @@
+package api
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"common-service/screening"
+
+	"github.com/gin-gonic/gin"
+)
+
+// screenWallet checks a wallet against the sanctions denylists before it is
+// bound to a KYC record. Matches are flagged, and the request is rejected
+// with an error response when the wallet is listed or cannot be screened.
+func (h *Handler) screenWallet(c *gin.Context, wallet string) bool {
+	matches, err := h.screening.Screen(c.Request.Context(), screening.Subject{Address: wallet, Source: screening.SourceKycWallet})
+	if err != nil {
+		log.Printf("[%s] Failed to screen wallet %s: %v", requestID(c), wallet, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to screen wallet"})
+		return false
+	}
+	if len(matches) > 0 {
+		log.Printf("[%s] Refused sanctioned wallet %s", requestID(c), wallet)
+		c.JSON(http.StatusForbidden, gin.H{"error": "Wallet address is not allowed"})
+		return false
+	}
+	return true
+}
+
+type FlaggedAddressesQueryParams struct {
+	Address string `form:"address" binding:"omitempty,eth_addr"`
+	Source  string `form:"source" binding:"omitempty,oneof=deposit withdrawal kyc_wallet"`
+	Limit   int32  `form:"limit" binding:"omitempty,min=1,max=1000"`
+	// Before is the id of the last flag of the previous page
+	Before int64 `form:"before" binding:"omitempty,min=1"`
+}
+
+// FlaggedAddressResponse is a denylist match of an address.
+type FlaggedAddressResponse struct {
+	ID        int64     `json:"id"`
+	Address   string    `json:"address"`
+	Source    string    `json:"source"`
+	ListName  string    `json:"list_name"`
+	ListEntry string    `json:"list_entry,omitempty"`
+	ChainID   int32     `json:"chain_id,omitempty"`
+	TxHash    string    `json:"tx_hash,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListFlaggedAddresses returns the addresses that matched a sanctions
+// denylist, newest first, optionally filtered by address and source.
+func (h *Handler) ListFlaggedAddresses(c *gin.Context) {
+	var queryParams FlaggedAddressesQueryParams
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if queryParams.Limit == 0 {
+		queryParams.Limit = 100
+	}
+	if queryParams.Before == 0 {
+		queryParams.Before = math.MaxInt64
+	}
+
+	flags, err := h.repo.ListFlaggedAddresses(c.Request.Context(), queryParams.Address, queryParams.Source, queryParams.Before, queryParams.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]FlaggedAddressResponse, len(flags))
+	for i, flag := range flags {
+		response[i] = FlaggedAddressResponse{
+			ID:        flag.ID,
+			Address:   flag.Address,
+			Source:    flag.Source,
+			ListName:  flag.ListName,
+			ListEntry: flag.ListEntry.String,
+			ChainID:   flag.ChainID.Int32,
+			TxHash:    flag.TxHash.String,
+			CreatedAt: flag.CreatedAt.Time,
+		}
+	}
+	result := gin.H{
+		"flags": response,
+		"count": len(response),
+	}
+	if len(flags) == int(queryParams.Limit) {
+		result["next_before"] = flags[len(flags)-1].ID
+	}
+	c.JSON(http.StatusOK, result)
+}
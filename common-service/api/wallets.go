@@ -0,0 +1,133 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/yourusername/yourrepo/db/sqlc"
+)
+
+// WalletResponse is a wallet linked to a KYC record.
+type WalletResponse struct {
+	WalletAddress string    `json:"wallet_address"`
+	LinkedAt      time.Time `json:"linked_at"`
+}
+
+// ListWallets returns the wallets linked to the KYC record of the session
+// wallet.
+func (h *Handler) ListWallets(c *gin.Context) {
+	citizenID := c.Param("citizenID")
+	if !h.requireOwnCitizen(c, citizenID) {
+		return
+	}
+	wallets, err := h.repo.ListKYCWallets(c.Request.Context(), citizenID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wallets"})
+		return
+	}
+
+	response := make([]WalletResponse, len(wallets))
+	for i, wallet := range wallets {
+		response[i] = WalletResponse{
+			WalletAddress: wallet.WalletAddress,
+			LinkedAt:      wallet.CreatedAt.Time,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"wallets": response, "max_wallets": h.maxWallets})
+}
+
+// LinkWallet links another wallet to the KYC record of the session wallet.
+// The new wallet has to sign its binding to the citizen ID with a fresh
+// nonce. Once the record is approved, the wallet gets its own NFT.
+func (h *Handler) LinkWallet(c *gin.Context) {
+	citizenID := c.Param("citizenID")
+	if !h.requireOwnCitizen(c, citizenID) {
+		return
+	}
+	var req WalletBinding
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if !h.verifyWalletBinding(c, citizenID, req) {
+		return
+	}
+
+	wallet := common.HexToAddress(req.WalletAddress).Hex()
+	if !h.screenWallet(c, wallet) {
+		return
+	}
+	kyc, err := h.repo.LinkWallet(c.Request.Context(), citizenID, wallet, req.WalletSignature, h.maxWallets)
+	switch {
+	case errors.Is(err, sqlc.ErrTooManyWallets):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "max_wallets": h.maxWallets})
+		return
+	case errors.Is(err, sqlc.ErrWalletLinked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("Failed to link wallet %s: %v", wallet, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link wallet"})
+		return
+	}
+
+	// Records that are not approved yet mint for all wallets on approval
+	mintQueued := false
+	switch kyc.Status {
+	case sqlc.KycStatusApproved, sqlc.KycStatusMinting, sqlc.KycStatusActive:
+		if err := h.producer.PublishStruct("kyc.mint", MintMessage{CitizenID: kyc.CitizenID, WalletAddress: wallet}); err != nil {
+			log.Printf("Failed to queue mint for wallet %s: %v", wallet, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Wallet linked but the mint could not be queued"})
+			return
+		}
+		mintQueued = true
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"wallet_address": wallet,
+		"status":         kyc.Status,
+		"mint_queued":    mintQueued,
+	})
+}
+
+// UnlinkWallet removes a wallet from the KYC record of the session wallet and
+// queues the burn of its NFT. The last wallet of a record cannot be removed.
+func (h *Handler) UnlinkWallet(c *gin.Context) {
+	citizenID := c.Param("citizenID")
+	if !h.requireOwnCitizen(c, citizenID) {
+		return
+	}
+	walletAddress := c.Param("walletAddress")
+	if !common.IsHexAddress(walletAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet address"})
+		return
+	}
+
+	// The burn job queued with the unlink is retried until the burn is
+	// confirmed; the worker skips wallets that never received an NFT
+	wallet := common.HexToAddress(walletAddress).Hex()
+	kyc, err := h.repo.UnlinkWallet(c.Request.Context(), citizenID, wallet)
+	switch {
+	case errors.Is(err, sqlc.ErrWalletNotLinked), errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": sqlc.ErrWalletNotLinked.Error()})
+		return
+	case errors.Is(err, sqlc.ErrLastWallet):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("Failed to unlink wallet %s: %v", walletAddress, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink wallet"})
+		return
+	}
+
+	burn := RevokeMessage{CitizenID: kyc.CitizenID, WalletAddress: wallet}
+	if err := h.producer.PublishStruct("kyc.revoke", burn); err != nil {
+		// The burn job is retried without the message
+		log.Printf("Failed to publish burn for wallet %s: %v", walletAddress, err)
+	}
+	c.JSON(http.StatusOK, gin.H{"wallet_address": walletAddress, "burn_queued": true})
+}
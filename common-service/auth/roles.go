@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Roles of the callers of the API. Wallet sessions have no role.
+const (
+	RoleVerifier = "verifier"
+	RoleAdmin    = "admin"
+)
+
+// Principal is a staff member or service authenticated by API key or token.
+type Principal struct {
+	Name string
+	Role string
+}
+
+// APIKeys maps the SHA-256 hashes of API keys to their principals.
+type APIKeys map[string]Principal
+
+// ParseAPIKeys parses a comma separated list of name:role:sha256-hex entries.
+// Only the hashes of the keys are configured so the environment does not hold
+// usable credentials.
+func ParseAPIKeys(spec string) (APIKeys, error) {
+	keys := make(APIKeys)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid API key entry %q, expected name:role:sha256", entry)
+		}
+		name, role, hash := parts[0], parts[1], strings.ToLower(parts[2])
+		if role != RoleVerifier && role != RoleAdmin {
+			return nil, fmt.Errorf("API key %q has unknown role %q", name, role)
+		}
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("API key %q is not a SHA-256 hex digest", name)
+		}
+		keys[hash] = Principal{Name: name, Role: role}
+	}
+	return keys, nil
+}
+
+// Lookup returns the principal of an API key.
+func (k APIKeys) Lookup(key string) (Principal, bool) {
+	sum := sha256.Sum256([]byte(key))
+	principal, ok := k[hex.EncodeToString(sum[:])]
+	return principal, ok
+}
+
+// HasRole reports whether the principal has one of the given roles. Admins
+// can do everything verifiers can.
+func (p Principal) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if p.Role == role || p.Role == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+// Package auth implements Sign-In-With-Ethereum logins and the session tokens
+// issued for them.
+package auth
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+
+// SiweMessage is a parsed EIP-4361 message.
+type SiweMessage struct {
+	Domain         string
+	Address        common.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+}
+
+// ParseSiweMessage parses the plain-text form of an EIP-4361 message.
+func ParseSiweMessage(raw string) (*SiweMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	if len(lines) < 3 || !strings.HasSuffix(lines[0], siweHeaderSuffix) {
+		return nil, fmt.Errorf("not a sign-in with ethereum message")
+	}
+
+	msg := &SiweMessage{Domain: strings.TrimSuffix(lines[0], siweHeaderSuffix)}
+	if i := strings.Index(msg.Domain, "://"); i >= 0 {
+		msg.Domain = msg.Domain[i+3:]
+	}
+	if !common.IsHexAddress(lines[1]) {
+		return nil, fmt.Errorf("invalid address %q", lines[1])
+	}
+	msg.Address = common.HexToAddress(lines[1])
+
+	inResources := false
+	for _, line := range lines[2:] {
+		key, value, isField := strings.Cut(line, ": ")
+		if inResources {
+			continue
+		}
+		if !isField {
+			if line == "Resources:" {
+				inResources = true
+			} else if line != "" && msg.URI == "" {
+				msg.Statement = line
+			}
+			continue
+		}
+
+		var err error
+		switch key {
+		case "URI":
+			msg.URI = value
+		case "Version":
+			msg.Version = value
+		case "Chain ID":
+			msg.ChainID, err = strconv.ParseInt(value, 10, 64)
+		case "Nonce":
+			msg.Nonce = value
+		case "Issued At":
+			msg.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, value)
+			msg.ExpirationTime = &t
+		case "Not Before":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, value)
+			msg.NotBefore = &t
+		case "Request ID":
+			msg.RequestID = value
+		default:
+			if msg.URI == "" {
+				msg.Statement = line
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", key, err)
+		}
+	}
+
+	if msg.URI == "" || msg.Version == "" || msg.Nonce == "" || msg.IssuedAt.IsZero() {
+		return nil, fmt.Errorf("message is missing URI, Version, Nonce or Issued At")
+	}
+	return msg, nil
+}
+
+// Validate checks the fields of a message that do not depend on its nonce or
+// signature.
+func (m *SiweMessage) Validate(domain string, chainID int64, now time.Time) error {
+	if m.Domain != domain {
+		return fmt.Errorf("message is for domain %q", m.Domain)
+	}
+	if m.Version != "1" {
+		return fmt.Errorf("unsupported version %q", m.Version)
+	}
+	if chainID != 0 && m.ChainID != chainID {
+		return fmt.Errorf("message is for chain %d", m.ChainID)
+	}
+	if m.ExpirationTime != nil && !m.ExpirationTime.After(now) {
+		return fmt.Errorf("message has expired")
+	}
+	if m.NotBefore != nil && m.NotBefore.After(now) {
+		return fmt.Errorf("message is not valid yet")
+	}
+	return nil
+}
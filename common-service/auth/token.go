@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Claims are the contents of a session token.
+type Claims struct {
+	// Subject is the checksummed wallet address of a session, or the name of a
+	// staff member for tokens with a role
+	Subject   string `json:"sub"`
+	Role      string `json:"role,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Sessions accepts Sign-In-With-Ethereum messages for one domain and chain and
+// issues HS256 JWT session tokens for them.
+type Sessions struct {
+	secret  []byte
+	ttl     time.Duration
+	domain  string
+	chainID int64
+}
+
+// NewSessions creates a new Sessions instance.
+func NewSessions(secret []byte, ttl time.Duration, domain string, chainID int64) *Sessions {
+	return &Sessions{secret: secret, ttl: ttl, domain: domain, chainID: chainID}
+}
+
+// Domain returns the domain login messages have to be issued for.
+func (s *Sessions) Domain() string {
+	return s.domain
+}
+
+// ValidateLogin checks that a login message was issued for this service and
+// is currently valid.
+func (s *Sessions) ValidateLogin(msg *SiweMessage) error {
+	return msg.Validate(s.domain, s.chainID, time.Now())
+}
+
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Issue returns a signed token for claims, filling in the issue and expiry times.
+func (s *Sessions) Issue(claims Claims) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = expiresAt.Unix()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	signingInput := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + s.sign(signingInput), expiresAt, nil
+}
+
+// Parse verifies a token and returns its claims.
+func (s *Sessions) Parse(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return nil, fmt.Errorf("malformed token")
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(s.sign(parts[0]+"."+parts[1]))) {
+		return nil, fmt.Errorf("invalid token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token")
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("malformed token")
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, fmt.Errorf("token has expired")
+	}
+	return &claims, nil
+}
+
+func (s *Sessions) sign(signingInput string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
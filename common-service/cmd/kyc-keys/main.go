@@ -0,0 +1,83 @@
+// Command kyc-keys maintains the encryption of KYC personal data.
+//
+//	kyc-keys encrypt   encrypts records stored before encryption was enabled
+//	kyc-keys rotate    re-wraps data keys with the current KMS key
+//
+// It reads the same DB_*, KYC_KMS_FILE and KYC_BLIND_INDEX_KEY variables as
+// the service. To rotate, add a new key to the key file, make it the current
+// key, restart the service and then run rotate; the old key can be removed
+// once rotate reports no remaining records.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+	"github.com/yourusername/yourrepo/db/sqlc"
+)
+
+func main() {
+	batch := flag.Int("batch", 100, "records processed per batch")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-batch n] encrypt|rotate\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *batch < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found or error loading it. Using environment variables.")
+	}
+
+	var run func(repo *sqlc.Repository, ctx context.Context, batch int32) (int, error)
+	switch flag.Arg(0) {
+	case "encrypt":
+		run = (*sqlc.Repository).EncryptLegacyKYC
+	case "rotate":
+		run = (*sqlc.Repository).RotateKYCKeys
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cipher, err := sqlc.LoadFieldCipher(os.Getenv("KYC_KMS_FILE"), os.Getenv("KYC_BLIND_INDEX_KEY"))
+	if err != nil {
+		log.Fatalf("Invalid KYC encryption configuration: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_PORT"),
+		os.Getenv("DB_NAME"),
+	)
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		log.Fatalf("Unable to connect to database: %v", err)
+	}
+	defer pool.Close()
+
+	repo := sqlc.NewRepository(pool, cipher)
+	total := 0
+	for {
+		done, err := run(repo, ctx, int32(*batch))
+		total += done
+		if err != nil {
+			log.Fatalf("%s stopped after %d records: %v", flag.Arg(0), total, err)
+		}
+		if done < *batch {
+			break
+		}
+	}
+	log.Printf("%s: %d records processed", flag.Arg(0), total)
+}
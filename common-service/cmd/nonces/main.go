@@ -0,0 +1,124 @@
+// Command nonces unsticks the transactions of the minting account.
+//
+//	nonces fill-gaps        sends a no-op for every nonce that was handed out but never sent
+//	nonces replace <hash>   resends a pending transaction with a higher gas price
+//
+// It reads the same DB_*, RPC_URL, SIGNER*, PRIVATE_KEY, KYC_CHAIN_ID and TX_*
+// variables as the service and only finds gaps with the database nonce store.
+// Stop the service first: nonces it is about to send look like gaps to another
+// process.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+
+	"common-service/txmgr"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+	"github.com/yourusername/yourrepo/db/sqlc"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s fill-gaps | replace <tx-hash>\n", os.Args[0])
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found or error loading it. Using environment variables.")
+	}
+
+	ctx := context.Background()
+	transactor, err := loadTransactor(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch {
+	case flag.Arg(0) == "fill-gaps" && flag.NArg() == 1:
+		filled, err := transactor.FillGaps(ctx)
+		if err != nil {
+			log.Fatalf("fill-gaps stopped after %d nonces: %v", filled, err)
+		}
+		log.Printf("fill-gaps: %d nonces filled", filled)
+	case flag.Arg(0) == "replace" && flag.NArg() == 2:
+		tx, pending, err := transactor.Client().TransactionByHash(ctx, common.HexToHash(flag.Arg(1)))
+		if err != nil {
+			log.Fatalf("Failed to get transaction: %v", err)
+		}
+		if !pending {
+			log.Fatalf("Transaction %s is already mined", tx.Hash().Hex())
+		}
+		replacement, err := transactor.Replace(ctx, tx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("replace: sent %s", replacement.Hash().Hex())
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func loadTransactor(ctx context.Context) (*txmgr.Transactor, error) {
+	chainID := int64(2021) // Ronin Saigon testnet chain ID
+	if v := os.Getenv("KYC_CHAIN_ID"); v != "" {
+		var err error
+		if chainID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid KYC_CHAIN_ID: %v", err)
+		}
+	}
+	backend := os.Getenv("SIGNER")
+	if backend == "" {
+		return nil, fmt.Errorf("SIGNER environment variable not set, use keystore, remote or key (development only)")
+	}
+	signer, err := txmgr.LoadSigner(ctx, txmgr.SignerConfig{
+		Backend:      backend,
+		PrivateKey:   os.Getenv("PRIVATE_KEY"),
+		KeystoreFile: os.Getenv("SIGNER_KEYSTORE_FILE"),
+		PasswordFile: os.Getenv("SIGNER_PASSWORD_FILE"),
+		URL:          os.Getenv("SIGNER_URL"),
+		Method:       os.Getenv("SIGNER_METHOD"),
+		Address:      os.Getenv("SIGNER_ADDRESS"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signer: %v", err)
+	}
+	client, err := ethclient.DialContext(ctx, os.Getenv("RPC_URL"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RPC: %v", err)
+	}
+
+	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_PORT"),
+		os.Getenv("DB_NAME"),
+	)
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to database: %v", err)
+	}
+	// Nonces are not encrypted, so no field cipher is needed
+	nonces := sqlc.NewRepository(pool, nil)
+	fees, err := txmgr.ParseFeeConfig(os.Getenv("TX_GAS_MARGIN"), os.Getenv("TX_MAX_FEE_GWEI"), os.Getenv("TX_MAX_TIP_GWEI"), os.Getenv("TX_BUMP_AFTER"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction fee configuration: %v", err)
+	}
+	// Transactions are sent without waiting for them
+	return txmgr.NewTransactor(client, signer, big.NewInt(chainID), nonces, fees, txmgr.WaitConfig{}), nil
+}
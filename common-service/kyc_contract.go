@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+
+	"common-service/txmgr"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const kycWalletNFTABI = `[
+  {"inputs":[{"internalType":"address","name":"to","type":"address"}],"name":"mint","outputs":[],"stateMutability":"nonpayable","type":"function"},
+  {"inputs":[{"internalType":"address","name":"from","type":"address"}],"name":"revoke","outputs":[],"stateMutability":"nonpayable","type":"function"},
+  {"inputs":[{"internalType":"address","name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"}
+]`
+
+// KycContract sends the mint and revoke transactions of the KYC NFT contract.
+type KycContract struct {
+	client     *ethclient.Client
+	address    common.Address
+	abi        abi.ABI
+	transactor *txmgr.Transactor
+}
+
+// NewKycContract creates a new KycContract instance.
+func NewKycContract(client *ethclient.Client, address common.Address, transactor *txmgr.Transactor) (*KycContract, error) {
+	parsedABI, err := abi.JSON(strings.NewReader(kycWalletNFTABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+	return &KycContract{
+		client:     client,
+		address:    address,
+		abi:        parsedABI,
+		transactor: transactor,
+	}, nil
+}
+
+// BalanceOf returns the number of KYC NFTs held by a wallet.
+func (k *KycContract) BalanceOf(ctx context.Context, owner common.Address) (*big.Int, error) {
+	input, err := k.abi.Pack("balanceOf", owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack balanceOf function: %v", err)
+	}
+	output, err := k.client.CallContract(ctx, ethereum.CallMsg{To: &k.address, Data: input}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call balanceOf: %v", err)
+	}
+	var balance *big.Int
+	if err := k.abi.UnpackIntoInterface(&balance, "balanceOf", output); err != nil {
+		return nil, fmt.Errorf("failed to unpack balanceOf: %v", err)
+	}
+	return balance, nil
+}
+
+// Build signs a call of a contract method that takes a wallet address without
+// sending it, see txmgr.Transactor.Build.
+func (k *KycContract) Build(ctx context.Context, method string, wallet common.Address) (*types.Transaction, error) {
+	log.Printf("Preparing %s transaction for address: %s", method, wallet.Hex())
+	input, err := k.abi.Pack(method, wallet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack %s function: %v", method, err)
+	}
+	return k.transactor.Build(ctx, k.address, input)
+}
+
+// Send sends a transaction returned by Build.
+func (k *KycContract) Send(ctx context.Context, tx *types.Transaction) error {
+	log.Println("Sending transaction...")
+	if err := k.transactor.Send(ctx, tx); err != nil {
+		return err
+	}
+	log.Printf("Transaction sent: %s", tx.Hash().Hex())
+	return nil
+}
+
+// Call calls a contract method that takes a wallet address and waits for the
+// transaction to be confirmed.
+func (k *KycContract) Call(ctx context.Context, method string, wallet common.Address) error {
+	tx, err := k.Build(ctx, method, wallet)
+	if err != nil {
+		return err
+	}
+	if err := k.Send(ctx, tx); err != nil {
+		return err
+	}
+	result, err := k.Wait(ctx, tx, method, nil)
+	if err != nil {
+		return err
+	}
+	if result.Outcome != txmgr.OutcomeConfirmed {
+		return fmt.Errorf("%s transaction %s was %s", method, result.Transaction.Hash().Hex(), result.Outcome)
+	}
+	return nil
+}
+
+// Wait waits for a transaction, or a replacement of it with higher fees, to
+// reach its outcome. onReplace is passed to txmgr.Transactor.Wait.
+func (k *KycContract) Wait(ctx context.Context, tx *types.Transaction, method string, onReplace func(*types.Transaction) error) (*txmgr.Result, error) {
+	log.Println("Waiting for transaction confirmation...")
+	result, err := k.transactor.Wait(ctx, tx, onReplace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for transaction %s: %v", tx.Hash().Hex(), err)
+	}
+	switch result.Outcome {
+	case txmgr.OutcomeConfirmed:
+		log.Printf("Transaction SUCCESS: %s confirmed in %s", method, result.Transaction.Hash().Hex())
+	case txmgr.OutcomeReverted:
+		log.Printf("Transaction FAILED: %s reverted in %s", method, result.Transaction.Hash().Hex())
+	default:
+		log.Printf("Transaction %s of %s was %s", result.Transaction.Hash().Hex(), method, result.Outcome)
+	}
+	return result, nil
+}
+
+// Transaction returns a transaction known to the node, pending or mined, or
+// nil when the node does not know it.
+func (k *KycContract) Transaction(ctx context.Context, hash common.Hash) (*types.Transaction, error) {
+	tx, _, err := k.client.TransactionByHash(ctx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// LoadKycContract connects to the KYC NFT contract configured by RPC_URL and
+// KYC_ADDRESS. Its transactions are signed by the account configured by
+// SIGNER, see loadSignerConfig.
+func LoadKycContract(chainID int64, nonces txmgr.NonceStore, fees txmgr.FeeConfig, wait txmgr.WaitConfig) (*KycContract, error) {
+	rpcURL := os.Getenv("RPC_URL")
+	if rpcURL == "" {
+		return nil, fmt.Errorf("RPC_URL environment variable not set")
+	}
+	contractAddress := common.HexToAddress(os.Getenv("KYC_ADDRESS"))
+	if contractAddress == (common.Address{}) {
+		return nil, fmt.Errorf("KYC_ADDRESS environment variable not set or invalid")
+	}
+	signerConfig, err := loadSignerConfig()
+	if err != nil {
+		return nil, err
+	}
+	signer, err := txmgr.LoadSigner(context.Background(), signerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signer: %v", err)
+	}
+
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RPC: %v", err)
+	}
+	transactor := txmgr.NewTransactor(client, signer, big.NewInt(chainID), nonces, fees, wait)
+	log.Printf("KYC NFT transactions are sent from %s", transactor.Account().Hex())
+	return NewKycContract(client, contractAddress, transactor)
+}
+
+// loadSignerConfig reads the signer of the minting account from SIGNER, which
+// has to be set: "keystore" decrypts SIGNER_KEYSTORE_FILE with the password in
+// SIGNER_PASSWORD_FILE, "remote" signs for SIGNER_ADDRESS at SIGNER_URL with
+// the JSON-RPC method SIGNER_METHOD, and "key" uses the raw PRIVATE_KEY,
+// which is for development only.
+func loadSignerConfig() (txmgr.SignerConfig, error) {
+	backend := os.Getenv("SIGNER")
+	if backend == "" {
+		return txmgr.SignerConfig{}, fmt.Errorf("SIGNER environment variable not set, use keystore, remote or key (development only)")
+	}
+	return txmgr.SignerConfig{
+		Backend:      backend,
+		PrivateKey:   os.Getenv("PRIVATE_KEY"),
+		KeystoreFile: os.Getenv("SIGNER_KEYSTORE_FILE"),
+		PasswordFile: os.Getenv("SIGNER_PASSWORD_FILE"),
+		URL:          os.Getenv("SIGNER_URL"),
+		Method:       os.Getenv("SIGNER_METHOD"),
+		Address:      os.Getenv("SIGNER_ADDRESS"),
+	}, nil
+}
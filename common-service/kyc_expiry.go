@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/yourusername/yourrepo/db/sqlc"
+	"github.com/yourusername/yourrepo/mq/rabbitmq"
+)
+
+// expiryWorkerActor is the audit actor of the status changes made by the expiry scheduler
+const expiryWorkerActor = "expiry-scheduler"
+
+// expiryBatchSize is the number of records handled per query by the scheduler
+const expiryBatchSize = 100
+
+// ValidityPolicy decides how long a KYC verification stays valid. When both
+// the nationality and the verifier of a record have a period configured, the
+// shorter one applies.
+type ValidityPolicy struct {
+	Default       time.Duration
+	ByNationality map[string]time.Duration
+	ByVerifier    map[string]time.Duration
+}
+
+// Validity returns the validity period of a record.
+func (p ValidityPolicy) Validity(kyc sqlc.KycInfo) time.Duration {
+	validity := time.Duration(0)
+	for _, period := range []time.Duration{p.ByNationality[kyc.Nationality.String], p.ByVerifier[kyc.Verifier.String]} {
+		if period > 0 && (validity == 0 || period < validity) {
+			validity = period
+		}
+	}
+	if validity == 0 {
+		return p.Default
+	}
+	return validity
+}
+
+// ParseValidityPeriods parses "key=duration" entries separated by commas, as
+// in "VN=4380h,US=8760h".
+func ParseValidityPeriods(spec string) (map[string]time.Duration, error) {
+	periods := make(map[string]time.Duration)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("entry %q is not key=duration", entry)
+		}
+		period, err := time.ParseDuration(value)
+		if err != nil || period <= 0 {
+			return nil, fmt.Errorf("entry %q has an invalid duration", entry)
+		}
+		periods[strings.TrimSpace(key)] = period
+	}
+	return periods, nil
+}
+
+// ExpiryScheduler expires KYC records at the end of their validity period.
+// Holders are notified with a kyc.expiring message notice ahead of the
+// expiry, and with revokeOnExpiry set the NFTs of expired records are burned.
+type ExpiryScheduler struct {
+	repo           *sqlc.Repository
+	producer       *rabbitmq.Producer
+	policy         ValidityPolicy
+	notice         time.Duration
+	revokeOnExpiry bool
+}
+
+// NewExpiryScheduler creates a new ExpiryScheduler instance.
+func NewExpiryScheduler(repo *sqlc.Repository, producer *rabbitmq.Producer, policy ValidityPolicy, notice time.Duration, revokeOnExpiry bool) *ExpiryScheduler {
+	return &ExpiryScheduler{
+		repo:           repo,
+		producer:       producer,
+		policy:         policy,
+		notice:         notice,
+		revokeOnExpiry: revokeOnExpiry,
+	}
+}
+
+// Run checks the records every interval until ctx is cancelled.
+func (s *ExpiryScheduler) Run(ctx context.Context, interval time.Duration) {
+	ctx = sqlc.WithAudit(ctx, sqlc.Audit{Actor: expiryWorkerActor})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.check(ctx); err != nil {
+			log.Printf("KYC expiry check failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ExpiryScheduler) check(ctx context.Context) error {
+	if err := s.assignExpiry(ctx); err != nil {
+		return fmt.Errorf("failed to assign expiry dates: %v", err)
+	}
+	if err := s.notifyExpiring(ctx); err != nil {
+		return fmt.Errorf("failed to notify expiring records: %v", err)
+	}
+	if err := s.expire(ctx); err != nil {
+		return fmt.Errorf("failed to expire records: %v", err)
+	}
+	return nil
+}
+
+// assignExpiry sets the expiry of approved records from their verification
+// time. Records keep their expiry when the policy changes later on.
+func (s *ExpiryScheduler) assignExpiry(ctx context.Context) error {
+	for {
+		records, err := s.repo.ListKYCWithoutExpiry(ctx, expiryBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, kyc := range records {
+			expiresAt := kyc.KycVerifiedAt.Time.Add(s.policy.Validity(kyc))
+			if err := s.repo.SetKYCExpiry(ctx, kyc.CitizenID, expiresAt); err != nil {
+				return err
+			}
+		}
+		if len(records) < expiryBatchSize {
+			return nil
+		}
+	}
+}
+
+// notifyExpiring publishes a kyc.expiring message for the records expiring
+// within the notice period.
+func (s *ExpiryScheduler) notifyExpiring(ctx context.Context) error {
+	for {
+		records, err := s.repo.ListKYCExpiringBefore(ctx, time.Now().Add(s.notice), expiryBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, kyc := range records {
+			wallets, err := s.repo.GetWalletsByCitizenID(ctx, kyc.CitizenID)
+			if err != nil {
+				return err
+			}
+			event := rabbitmq.KycExpiringEvent{WalletAddresses: wallets, ExpiresAt: kyc.ExpiresAt.Time}
+			if err := s.producer.PublishStructContext(ctx, rabbitmq.RoutingKeyKycExpiring, event); err != nil {
+				return err
+			}
+			if err := s.repo.MarkKYCExpiryNotified(ctx, kyc.CitizenID); err != nil {
+				return err
+			}
+		}
+		if len(records) < expiryBatchSize {
+			return nil
+		}
+	}
+}
+
+// expire moves the records past their expiry to expired and, if configured,
+// queues the burn of their NFTs.
+func (s *ExpiryScheduler) expire(ctx context.Context) error {
+	for {
+		records, err := s.repo.ListExpiredKYC(ctx, expiryBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, kyc := range records {
+			if _, err := s.repo.TransitionKYC(ctx, kyc.CitizenID, sqlc.KycStatusExpired, "validity period ended"); err != nil {
+				return err
+			}
+			if s.revokeOnExpiry {
+				if err := s.revokeNFTs(ctx, kyc.CitizenID); err != nil {
+					return err
+				}
+			}
+		}
+		if len(records) > 0 {
+			log.Printf("Expired %d KYC records", len(records))
+		}
+		if len(records) < expiryBatchSize {
+			return nil
+		}
+	}
+}
+
+// revokeNFTs queues the burn of the NFT of every wallet of a record. Each
+// burn is recorded as a burn job first, which the revoke worker retries until
+// the burn is confirmed, even if the message is lost.
+func (s *ExpiryScheduler) revokeNFTs(ctx context.Context, citizenID string) error {
+	wallets, err := s.repo.GetWalletsByCitizenID(ctx, citizenID)
+	if err != nil {
+		return err
+	}
+	for _, wallet := range wallets {
+		if err := s.repo.QueueBurnJob(ctx, citizenID, wallet); err != nil {
+			return err
+		}
+		burn := RevokeMessage{CitizenID: citizenID, WalletAddress: wallet}
+		if err := s.producer.PublishStructContext(ctx, "kyc.revoke", burn); err != nil {
+			log.Printf("Failed to publish burn for wallet %s, its burn job is retried: %v", wallet, err)
+		}
+	}
+	return nil
+}
@@ -6,8 +6,16 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"common-service/api"
+	"common-service/auth"
+	"common-service/merkle"
+	"common-service/screening"
+	"common-service/sigverify"
+	"common-service/txmgr"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
@@ -60,8 +68,14 @@ func main() {
 	}
 	log.Println("Successfully connected to database!")
 
+	// KYC personal data is envelope-encrypted with keys from the KMS
+	cipher, err := sqlc.LoadFieldCipher(os.Getenv("KYC_KMS_FILE"), os.Getenv("KYC_BLIND_INDEX_KEY"))
+	if err != nil {
+		log.Fatalf("Invalid KYC encryption configuration: %v", err)
+	}
+
 	// Initialize queries and repository
-	repo := sqlc.NewRepository(sqlc.New(pool))
+	repo := sqlc.NewRepository(pool, cipher)
 
 	// Initialize RabbitMQ producer
 	producer, err := rabbitmq.NewProducer(os.Getenv("RABBITMQ_URL"), "kyc-mint-exchange", "topic")
@@ -70,17 +84,166 @@ func main() {
 	}
 	defer producer.Close()
 
+	// Deposit trees are built lazily per contract from the indexed leaves
+	trees := merkle.NewStore(repo, merkle.DefaultLevels)
+
+	// Compare the computed roots with the mixer contracts
+	rpcURLs := os.Getenv("MERKLE_RPC_URLS")
+	if rpcURLs == "" {
+		rpcURLs = os.Getenv("RPC_URL")
+	}
+	checkInterval := time.Minute
+	if v := os.Getenv("ROOT_CHECK_INTERVAL"); v != "" {
+		if checkInterval, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("Invalid ROOT_CHECK_INTERVAL: %v", err)
+		}
+	}
+	var rpcURLList []string
+	if rpcURLs != "" {
+		rpcURLList = strings.Split(rpcURLs, ",")
+	}
+	// Without RPC URLs the checker still records the root history
+	checker, err := merkle.NewRootChecker(context.Background(), trees, repo, producer, rpcURLList, checkInterval)
+	if err != nil {
+		log.Printf("Merkle root check disabled: %v", err)
+	} else {
+		go checker.Run(context.Background())
+	}
+
+	// Wallet signatures are EIP-712 scoped to this chain
+	kycChainID := int64(2021) // Ronin Saigon testnet chain ID
+	if v := os.Getenv("KYC_CHAIN_ID"); v != "" {
+		if kycChainID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			log.Fatalf("Invalid KYC_CHAIN_ID: %v", err)
+		}
+	}
+	signatures := sigverify.NewVerifier(kycChainID)
+
+	// Sign-In-With-Ethereum sessions
+	sessionSecret := os.Getenv("SESSION_SECRET")
+	if sessionSecret == "" {
+		log.Fatalf("SESSION_SECRET environment variable is not set")
+	}
+	sessionTTL := 12 * time.Hour
+	if v := os.Getenv("SESSION_TTL"); v != "" {
+		if sessionTTL, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("Invalid SESSION_TTL: %v", err)
+		}
+	}
+	siweDomain := os.Getenv("SIWE_DOMAIN")
+	if siweDomain == "" {
+		siweDomain = "localhost:3000"
+	}
+	sessions := auth.NewSessions([]byte(sessionSecret), sessionTTL, siweDomain, kycChainID)
+
+	// Verifier and admin API keys, configured as name:role:sha256-of-key entries
+	apiKeys, err := auth.ParseAPIKeys(os.Getenv("API_KEYS"))
+	if err != nil {
+		log.Fatalf("Invalid API_KEYS: %v", err)
+	}
+
+	// Number of wallets that can be linked to one KYC record
+	maxWallets := 5
+	if v := os.Getenv("KYC_MAX_WALLETS"); v != "" {
+		if maxWallets, err = strconv.Atoi(v); err != nil || maxWallets < 1 {
+			log.Fatalf("Invalid KYC_MAX_WALLETS: %s", v)
+		}
+	}
+
+	// Validity of KYC verifications, by default and per nationality or verifier
+	validity := ValidityPolicy{Default: 365 * 24 * time.Hour}
+	if v := os.Getenv("KYC_VALIDITY"); v != "" {
+		if validity.Default, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("Invalid KYC_VALIDITY: %v", err)
+		}
+	}
+	if validity.ByNationality, err = ParseValidityPeriods(os.Getenv("KYC_VALIDITY_BY_NATIONALITY")); err != nil {
+		log.Fatalf("Invalid KYC_VALIDITY_BY_NATIONALITY: %v", err)
+	}
+	if validity.ByVerifier, err = ParseValidityPeriods(os.Getenv("KYC_VALIDITY_BY_VERIFIER")); err != nil {
+		log.Fatalf("Invalid KYC_VALIDITY_BY_VERIFIER: %v", err)
+	}
+	expiryNotice := 30 * 24 * time.Hour
+	if v := os.Getenv("KYC_EXPIRY_NOTICE"); v != "" {
+		if expiryNotice, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("Invalid KYC_EXPIRY_NOTICE: %v", err)
+		}
+	}
+	expiryInterval := time.Hour
+	if v := os.Getenv("KYC_EXPIRY_CHECK_INTERVAL"); v != "" {
+		if expiryInterval, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("Invalid KYC_EXPIRY_CHECK_INTERVAL: %v", err)
+		}
+	}
+	revokeOnExpiry := os.Getenv("KYC_REVOKE_ON_EXPIRY") == "true"
+
+	// Sanctions denylists, as comma separated paths of CSV or JSON files
+	var sanctionsLists []string
+	if v := os.Getenv("SANCTIONS_LISTS"); v != "" {
+		sanctionsLists = strings.Split(v, ",")
+	}
+	screener, err := screening.NewScreener(sanctionsLists)
+	if err != nil {
+		log.Fatalf("Failed to load SANCTIONS_LISTS: %v", err)
+	}
+	reloadInterval := time.Minute
+	if v := os.Getenv("SANCTIONS_RELOAD_INTERVAL"); v != "" {
+		if reloadInterval, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("Invalid SANCTIONS_RELOAD_INTERVAL: %v", err)
+		}
+	}
+	screeningService := screening.NewService(screener, repo, producer)
+
 	// Initialize handler with producer
-	handler := api.NewHandler(repo, producer)
+	handler := api.NewHandler(repo, producer, trees, signatures, sessions, apiKeys, maxWallets, screeningService)
 
 	// Setup router
 	router := api.SetupRouter(handler)
 
-	// Start mint worker in a goroutine
-	go func() {
-		log.Println("Starting mint worker...")
-		StartMintWorker(repo, os.Getenv("RABBITMQ_URL"))
-	}()
+	// Nonces of the minting account are kept in the database so they survive
+	// restarts, or in memory with NONCE_STORE=memory
+	var nonces txmgr.NonceStore = repo
+	switch v := os.Getenv("NONCE_STORE"); v {
+	case "", "db":
+	case "memory":
+		nonces = txmgr.NewMemoryNonceStore()
+	default:
+		log.Fatalf("Invalid NONCE_STORE: %s", v)
+	}
+	// Gas and fee limits of the minting transactions
+	fees, err := txmgr.ParseFeeConfig(os.Getenv("TX_GAS_MARGIN"), os.Getenv("TX_MAX_FEE_GWEI"), os.Getenv("TX_MAX_TIP_GWEI"), os.Getenv("TX_BUMP_AFTER"))
+	if err != nil {
+		log.Fatalf("Invalid transaction fee configuration: %v", err)
+	}
+	// Confirmations and time limits of waiting for them
+	wait, err := txmgr.ParseWaitConfig(os.Getenv("TX_CONFIRMATIONS"), os.Getenv("TX_WAIT_TIMEOUT"), os.Getenv("TX_DROPPED_AFTER"))
+	if err != nil {
+		log.Fatalf("Invalid transaction wait configuration: %v", err)
+	}
+	contract, err := LoadKycContract(kycChainID, nonces, fees, wait)
+	if err != nil {
+		log.Printf("KYC NFT mint and revoke workers disabled: %v", err)
+	} else {
+		// Start mint worker in a goroutine
+		go StartMintWorker(repo, contract, os.Getenv("RABBITMQ_URL"))
+
+		// Start revoke worker in a goroutine
+		go StartRevokeWorker(repo, contract, os.Getenv("RABBITMQ_URL"))
+	}
+
+	// Expire KYC records at the end of their validity period
+	go NewExpiryScheduler(repo, producer, validity, expiryNotice, revokeOnExpiry).Run(context.Background(), expiryInterval)
+
+	// Screen new deposits and withdrawals, and every KYC wallet whenever the
+	// denylists change
+	go StartScreeningWorker(screeningService, os.Getenv("RABBITMQ_URL"))
+	screenKYCWallets := func() {
+		if err := screeningService.ScreenKYCWallets(context.Background()); err != nil {
+			log.Printf("Failed to screen KYC wallets: %v", err)
+		}
+	}
+	go screenKYCWallets()
+	go screener.Watch(context.Background(), reloadInterval, screenKYCWallets)
 
 	// Start server
 	log.Println("Server starting on :8080")
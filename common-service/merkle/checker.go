@@ -0,0 +1,222 @@
+package merkle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/yourusername/yourrepo/db/sqlc"
+	"github.com/yourusername/yourrepo/mq/rabbitmq"
+)
+
+// RootHistorySize is the size of the mixer's ring buffer of known roots.
+const RootHistorySize = 30
+
+// View functions of MerkleTreeWithHistory used by the root check
+const mixerTreeABI = `[
+  {"inputs":[],"name":"getLastRoot","outputs":[{"internalType":"bytes32","name":"","type":"bytes32"}],"stateMutability":"view","type":"function"},
+  {"inputs":[{"internalType":"bytes32","name":"_root","type":"bytes32"}],"name":"isKnownRoot","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"},
+  {"inputs":[],"name":"nextIndex","outputs":[{"internalType":"uint32","name":"","type":"uint32"}],"stateMutability":"view","type":"function"}
+]`
+
+// RootCheck is the outcome of comparing a computed tree with its contract.
+type RootCheck struct {
+	CheckedAt     time.Time `json:"checked_at"`
+	Leaves        int       `json:"leaves"`
+	Root          string    `json:"root"`
+	OnChainLeaves uint32    `json:"on_chain_leaves"`
+	OnChainRoot   string    `json:"on_chain_root"`
+	// KnownOnChain reports whether the contract still accepts our root
+	KnownOnChain bool   `json:"known_on_chain"`
+	Consistent   bool   `json:"consistent"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// RootChecker periodically compares every computed tree with getLastRoot()
+// and isKnownRoot() of its contract. A contract that becomes inconsistent is
+// reported with a merkle.divergence alert. Syncing the trees also records the
+// root history of every contract, see Store.
+type RootChecker struct {
+	trees    *Store
+	repo     *sqlc.Repository
+	producer *rabbitmq.Producer
+	clients  map[int32]*ethclient.Client
+	abi      abi.ABI
+	interval time.Duration
+}
+
+// NewRootChecker creates a new RootChecker instance. Each RPC URL is asked for
+// its chain ID; contracts on chains without an RPC URL are not checked.
+func NewRootChecker(ctx context.Context, trees *Store, repo *sqlc.Repository, producer *rabbitmq.Producer, rpcURLs []string, interval time.Duration) (*RootChecker, error) {
+	parsed, err := abi.JSON(strings.NewReader(mixerTreeABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mixer ABI: %v", err)
+	}
+
+	clients := make(map[int32]*ethclient.Client)
+	for _, url := range rpcURLs {
+		client, err := ethclient.DialContext(ctx, url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Ethereum RPC: %v", err)
+		}
+		chainID, err := client.ChainID(ctx)
+		if err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to get chain ID: %v", err)
+		}
+		clients[int32(chainID.Int64())] = client
+	}
+
+	return &RootChecker{
+		trees:    trees,
+		repo:     repo,
+		producer: producer,
+		clients:  clients,
+		abi:      parsed,
+		interval: interval,
+	}, nil
+}
+
+// Run checks every contract each interval until ctx is cancelled.
+func (rc *RootChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(rc.interval)
+	defer ticker.Stop()
+
+	for {
+		rc.checkAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (rc *RootChecker) checkAll(ctx context.Context) {
+	contracts, err := rc.repo.ListDepositContracts(ctx)
+	if err != nil {
+		log.Printf("Failed to list mixer contracts: %v", err)
+		return
+	}
+
+	for _, contract := range contracts {
+		netId := strconv.Itoa(int(contract.ChainID.Int32))
+		address := contract.ContractAddress.String
+
+		client, ok := rc.clients[contract.ChainID.Int32]
+		if !ok {
+			// Still keep the root history up to date
+			if _, _, err := rc.trees.Sync(ctx, netId, address, false); err != nil {
+				log.Printf("Failed to sync merkle tree of %s on chain %s: %v", address, netId, err)
+			}
+			continue
+		}
+
+		check, err := rc.check(ctx, client, netId, address)
+		if err != nil {
+			log.Printf("Failed to check merkle root of %s on chain %s: %v", address, netId, err)
+			continue
+		}
+		previous := rc.trees.LastCheck(netId, address)
+		rc.trees.setCheck(netId, address, check)
+		if !check.Consistent {
+			log.Printf("ALERT: MERKLE ROOT DIVERGENCE on chain %s contract %s: %s (computed root %s over %d leaves, contract root %s over %d leaves)",
+				netId, address, check.Reason, check.Root, check.Leaves, check.OnChainRoot, check.OnChainLeaves)
+			// Alert once per divergence rather than on every check
+			if previous == nil || previous.Consistent || previous.Reason != check.Reason {
+				rc.alert(ctx, contract.ChainID.Int32, address, check)
+			}
+		}
+	}
+}
+
+// alert publishes a merkle.divergence event for an inconsistent check.
+func (rc *RootChecker) alert(ctx context.Context, chainID int32, contractAddress string, check *RootCheck) {
+	event := rabbitmq.MerkleRootAlertEvent{
+		ChainID:         chainID,
+		ContractAddress: contractAddress,
+		Reason:          check.Reason,
+		Root:            check.Root,
+		Leaves:          check.Leaves,
+		OnChainRoot:     check.OnChainRoot,
+		OnChainLeaves:   check.OnChainLeaves,
+	}
+	if err := rc.producer.PublishStructContext(ctx, rabbitmq.RoutingKeyMerkleRootDivergence, event); err != nil {
+		log.Printf("Failed to publish merkle root alert: %v", err)
+	}
+}
+
+// check syncs one tree and compares it with the contract state.
+func (rc *RootChecker) check(ctx context.Context, client *ethclient.Client, netId string, contractAddress string) (*RootCheck, error) {
+	// The contract's root covers every mined deposit, confirmed or not
+	root, leaves, err := rc.trees.Sync(ctx, netId, contractAddress, false)
+	if err != nil {
+		return nil, err
+	}
+	contract := common.HexToAddress(contractAddress)
+
+	var nextIndex uint32
+	if err := rc.call(ctx, client, contract, &nextIndex, "nextIndex"); err != nil {
+		return nil, err
+	}
+	var lastRoot [32]byte
+	if err := rc.call(ctx, client, contract, &lastRoot, "getLastRoot"); err != nil {
+		return nil, err
+	}
+	var known bool
+	if err := rc.call(ctx, client, contract, &known, "isKnownRoot", common.BigToHash(root)); err != nil {
+		return nil, err
+	}
+
+	check := &RootCheck{
+		CheckedAt:     time.Now(),
+		Leaves:        leaves,
+		Root:          FormatNode(root),
+		OnChainLeaves: nextIndex,
+		OnChainRoot:   common.Hash(lastRoot).Hex(),
+		KnownOnChain:  known,
+		Consistent:    true,
+	}
+
+	switch {
+	case uint32(leaves) == nextIndex && check.Root != check.OnChainRoot:
+		check.Consistent = false
+		check.Reason = "same number of leaves but different roots"
+	case uint32(leaves) > nextIndex:
+		check.Consistent = false
+		check.Reason = "more leaves indexed than the contract holds"
+	case !known && nextIndex-uint32(leaves) < RootHistorySize:
+		// A lagging index is fine as long as our root is still in the history
+		check.Consistent = false
+		check.Reason = "computed root is not a known root of the contract"
+	case !known:
+		check.Reason = "index lags too far behind the contract to compare roots"
+	}
+	return check, nil
+}
+
+func (rc *RootChecker) call(ctx context.Context, client *ethclient.Client, contract common.Address, out interface{}, method string, args ...interface{}) error {
+	input, err := rc.abi.Pack(method, args...)
+	if err != nil {
+		return err
+	}
+	output, err := client.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: input}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %v", method, err)
+	}
+	values, err := rc.abi.Unpack(method, output)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %v", method, err)
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("unexpected %s result", method)
+	}
+	return rc.abi.Methods[method].Outputs.Copy(out, values)
+}
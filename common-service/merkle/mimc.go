@@ -0,0 +1,67 @@
+package merkle
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// mimcRounds is the number of Feistel rounds of circomlib's MiMCSponge.
+const mimcRounds = 220
+
+// FieldSize is the BN254 scalar field modulus every tree value lives in.
+var FieldSize, _ = new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+
+// mimcConstants are the round constants of circomlib's MiMCSponge: a keccak256
+// chain seeded with "mimcsponge", with the first and last constant set to zero.
+var mimcConstants = func() []*big.Int {
+	constants := make([]*big.Int, mimcRounds)
+	c := crypto.Keccak256([]byte("mimcsponge"))
+	constants[0] = new(big.Int)
+	for i := 1; i < mimcRounds; i++ {
+		c = crypto.Keccak256(c)
+		constants[i] = new(big.Int).Mod(new(big.Int).SetBytes(c), FieldSize)
+	}
+	constants[mimcRounds-1] = new(big.Int)
+	return constants
+}()
+
+// mimcSponge is the MiMC Feistel permutation exposed by the on-chain hasher as
+// MiMCSponge(xL, xR), with key 0.
+func mimcSponge(xL, xR *big.Int) (*big.Int, *big.Int) {
+	xL = new(big.Int).Set(xL)
+	xR = new(big.Int).Set(xR)
+	t := new(big.Int)
+	t5 := new(big.Int)
+
+	for i := 0; i < mimcRounds; i++ {
+		t.Add(xL, mimcConstants[i])
+		t.Mod(t, FieldSize)
+
+		// t^5
+		t5.Mul(t, t)
+		t5.Mod(t5, FieldSize)
+		t5.Mul(t5, t5)
+		t5.Mod(t5, FieldSize)
+		t5.Mul(t5, t)
+
+		next := new(big.Int).Add(xR, t5)
+		next.Mod(next, FieldSize)
+		if i < mimcRounds-1 {
+			xL, xR = next, xL
+		} else {
+			xR = next
+		}
+	}
+	return xL, xR
+}
+
+// HashLeftRight hashes two tree nodes the way the mixer's
+// MerkleTreeWithHistory.hashLeftRight does.
+func HashLeftRight(left, right *big.Int) *big.Int {
+	r, c := mimcSponge(left, new(big.Int))
+	r = new(big.Int).Add(r, right)
+	r.Mod(r, FieldSize)
+	r, _ = mimcSponge(r, c)
+	return r
+}
@@ -0,0 +1,247 @@
+package merkle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/yourusername/yourrepo/db/sqlc"
+)
+
+// Store keeps two trees per (chain, contract), built from the deposits indexed
+// by the blockchain listener and caught up on demand: one of every deposit and
+// one of the confirmed deposits only, whose roots a reorg cannot remove.
+//
+// The root after every deposit is recorded when the tree of every deposit
+// catches up, i.e. on proof and root requests and on every RootChecker run.
+// The root history is therefore filled in lazily, but without gaps.
+type Store struct {
+	repo   *sqlc.Repository
+	levels int
+
+	mu    sync.Mutex
+	trees map[string]*entry
+}
+
+type entry struct {
+	mu   sync.Mutex
+	tree *Tree
+	// check is the outcome of the last on-chain root comparison, if any
+	check *RootCheck
+}
+
+// NewStore creates a new Store instance.
+func NewStore(repo *sqlc.Repository, levels int) *Store {
+	return &Store{
+		repo:   repo,
+		levels: levels,
+		trees:  make(map[string]*entry),
+	}
+}
+
+// ErrLeafNotFound is returned when a commitment is not part of a tree.
+var ErrLeafNotFound = fmt.Errorf("commitment not found in tree")
+
+// ErrInvalidCommitment is returned when a commitment is not a 0x-prefixed
+// 32-byte hex value.
+var ErrInvalidCommitment = fmt.Errorf("invalid commitment")
+
+// Proof syncs the tree of a contract and returns the path of commitment
+// together with its leaf index. With confirmedOnly, the proof is made against
+// the tree of confirmed deposits.
+func (s *Store) Proof(ctx context.Context, netId string, contractAddress string, commitment string, confirmedOnly bool) (*Proof, int, error) {
+	target, err := parseNode(commitment)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidCommitment, err)
+	}
+
+	e := s.entry(netId, contractAddress, confirmedOnly)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := s.sync(ctx, e, netId, contractAddress, confirmedOnly); err != nil {
+		return nil, 0, err
+	}
+
+	for i := e.tree.Len() - 1; i >= 0; i-- {
+		if e.tree.Leaf(i).Cmp(target) == 0 {
+			proof, err := e.tree.Proof(i)
+			return proof, i, err
+		}
+	}
+	return nil, 0, ErrLeafNotFound
+}
+
+// Sync catches the tree of a contract up with the indexed deposits, or only
+// the confirmed ones with confirmedOnly, and returns its root and number of
+// leaves.
+func (s *Store) Sync(ctx context.Context, netId string, contractAddress string, confirmedOnly bool) (*big.Int, int, error) {
+	e := s.entry(netId, contractAddress, confirmedOnly)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := s.sync(ctx, e, netId, contractAddress, confirmedOnly); err != nil {
+		return nil, 0, err
+	}
+	return e.tree.Root(), e.tree.Len(), nil
+}
+
+// LastCheck returns the last on-chain root comparison of a contract, or nil
+// when it has not been checked yet.
+func (s *Store) LastCheck(netId string, contractAddress string) *RootCheck {
+	e := s.entry(netId, contractAddress, false)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.check
+}
+
+func (s *Store) setCheck(netId string, contractAddress string, check *RootCheck) {
+	e := s.entry(netId, contractAddress, false)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.check = check
+}
+
+func (s *Store) entry(netId string, contractAddress string, confirmedOnly bool) *entry {
+	key := netId + ":" + contractAddress
+	if confirmedOnly {
+		key += ":confirmed"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.trees[key]
+	if !ok {
+		e = &entry{tree: NewTree(s.levels)}
+		s.trees[key] = e
+	}
+	return e
+}
+
+// sync appends the leaves indexed since the last call. The last known leaf is
+// fetched again: if it changed or disappeared, the deposits were rolled back
+// by a reorg and the tree is rebuilt from scratch. The tree of every deposit
+// records the root after each leaf; the confirmed tree is a prefix of it and
+// leaves the recorded roots alone. The caller must hold e.mu.
+func (s *Store) sync(ctx context.Context, e *entry, netId string, contractAddress string, confirmedOnly bool) error {
+	from := e.tree.Len() - 1
+	if from < 0 {
+		from = 0
+	}
+	rows, err := s.repo.GetLeavesFromIndex(ctx, netId, contractAddress, int32(from), confirmedOnly)
+	if err != nil {
+		return fmt.Errorf("failed to load leaves: %v", err)
+	}
+
+	if e.tree.Len() > 0 {
+		if len(rows) == 0 || int(rows[0].LeafIndex.Int32) != from || !sameNode(rows[0].Commitment.String, e.tree.Leaf(from)) {
+			log.Printf("Leaves of %s on chain %s changed, rebuilding merkle tree", contractAddress, netId)
+			e.tree = NewTree(s.levels)
+			if err := s.sync(ctx, e, netId, contractAddress, confirmedOnly); err != nil {
+				return err
+			}
+			if confirmedOnly {
+				return nil
+			}
+			// Forget the roots of deposits that no longer exist
+			return s.repo.DeleteMerkleRootsFrom(ctx, netId, contractAddress, int32(e.tree.Len()))
+		}
+		rows = rows[1:]
+	}
+
+	for _, row := range rows {
+		// Stop at a gap: the listener has not indexed the missing deposit yet
+		if int(row.LeafIndex.Int32) != e.tree.Len() {
+			break
+		}
+		leaf, err := parseNode(row.Commitment.String)
+		if err != nil {
+			return fmt.Errorf("invalid commitment at leaf %d: %v", row.LeafIndex.Int32, err)
+		}
+		if err := e.tree.Insert(leaf); err != nil {
+			return err
+		}
+		if confirmedOnly {
+			continue
+		}
+		if err := s.repo.SaveMerkleRoot(ctx, netId, contractAddress, row.LeafIndex.Int32, FormatNode(e.tree.Root())); err != nil {
+			// Start over next time so the missing root gets recorded
+			e.tree = NewTree(s.levels)
+			return fmt.Errorf("failed to record merkle root: %v", err)
+		}
+	}
+	return nil
+}
+
+// RootAge is a recorded root together with how many deposits were made after it.
+type RootAge struct {
+	LeafIndex int32  `json:"leaf_index"`
+	Root      string `json:"root"`
+	// Age is the number of deposits inserted after this root
+	Age int `json:"age"`
+	// InHistory reports whether the root is young enough to still be in the
+	// contract's ring buffer of known roots
+	InHistory bool `json:"in_history"`
+	// CreatedAt is when the root was recorded, which can be after its deposit
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Roots syncs the tree of a contract and returns its latest limit roots,
+// newest first. With confirmedOnly, only the roots of confirmed deposits are
+// returned.
+func (s *Store) Roots(ctx context.Context, netId string, contractAddress string, limit int32, confirmedOnly bool) ([]RootAge, error) {
+	// The roots are recorded by the tree of every deposit
+	_, leaves, err := s.Sync(ctx, netId, contractAddress, false)
+	if err != nil {
+		return nil, err
+	}
+	before := leaves
+	if confirmedOnly {
+		if _, before, err = s.Sync(ctx, netId, contractAddress, true); err != nil {
+			return nil, err
+		}
+	}
+	rows, err := s.repo.GetMerkleRoots(ctx, netId, contractAddress, int32(before), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load merkle roots: %v", err)
+	}
+
+	roots := make([]RootAge, len(rows))
+	for i, row := range rows {
+		age := leaves - 1 - int(row.LeafIndex)
+		roots[i] = RootAge{
+			LeafIndex: row.LeafIndex,
+			Root:      row.Root,
+			Age:       age,
+			InHistory: age < RootHistorySize,
+			CreatedAt: row.CreatedAt.Time,
+		}
+	}
+	return roots, nil
+}
+
+// parseNode parses a 0x-prefixed 32-byte hex value.
+func parseNode(value string) (*big.Int, error) {
+	if !strings.HasPrefix(value, "0x") || len(value) != 66 {
+		return nil, fmt.Errorf("invalid bytes32 value %q", value)
+	}
+	n, ok := new(big.Int).SetString(value[2:], 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid bytes32 value %q", value)
+	}
+	return n, nil
+}
+
+func sameNode(value string, node *big.Int) bool {
+	n, err := parseNode(value)
+	return err == nil && n.Cmp(node) == 0
+}
+
+// FormatNode formats a tree value as a 0x-prefixed 32-byte hex string.
+func FormatNode(node *big.Int) string {
+	return common.BigToHash(node).Hex()
+}
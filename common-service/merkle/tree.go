@@ -0,0 +1,119 @@
+package merkle
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// DefaultLevels is the height of the mixer contract's tree (MERKLE_TREE_HEIGHT).
+const DefaultLevels = 20
+
+// ZeroValue is the empty leaf of the mixer contract, keccak256("tornado") mod FieldSize.
+var ZeroValue, _ = new(big.Int).SetString("21663839004416932945382355908790599225266501822907911457504978515578255421292", 10)
+
+// Tree is an append-only Merkle tree matching the mixer's
+// MerkleTreeWithHistory. Every layer is kept so proofs can be produced for
+// any leaf.
+type Tree struct {
+	levels int
+	zeros  []*big.Int
+	// layers[0] holds the leaves, layers[levels] the root once a leaf exists
+	layers [][]*big.Int
+}
+
+// Proof is a Merkle path from a leaf to the root.
+type Proof struct {
+	Root         *big.Int
+	PathElements []*big.Int
+	// PathIndices[i] is 1 when the node at level i is a right child
+	PathIndices []int
+}
+
+// NewTree creates an empty tree with the given number of levels.
+func NewTree(levels int) *Tree {
+	zeros := make([]*big.Int, levels+1)
+	zeros[0] = ZeroValue
+	for i := 1; i <= levels; i++ {
+		zeros[i] = HashLeftRight(zeros[i-1], zeros[i-1])
+	}
+	return &Tree{
+		levels: levels,
+		zeros:  zeros,
+		layers: make([][]*big.Int, levels+1),
+	}
+}
+
+// Len returns the number of leaves in the tree.
+func (t *Tree) Len() int {
+	return len(t.layers[0])
+}
+
+// Leaf returns the leaf at index.
+func (t *Tree) Leaf(index int) *big.Int {
+	return t.layers[0][index]
+}
+
+// Insert appends a leaf and updates the path above it.
+func (t *Tree) Insert(leaf *big.Int) error {
+	if leaf.Sign() < 0 || leaf.Cmp(FieldSize) >= 0 {
+		return fmt.Errorf("leaf %s is outside the field", leaf.String())
+	}
+	index := t.Len()
+	if index >= 1<<t.levels {
+		return fmt.Errorf("merkle tree is full")
+	}
+
+	t.layers[0] = append(t.layers[0], leaf)
+	node := leaf
+	for level := 0; level < t.levels; level++ {
+		var left, right *big.Int
+		if index%2 == 0 {
+			left, right = node, t.zeros[level]
+		} else {
+			left, right = t.layers[level][index-1], node
+		}
+		node = HashLeftRight(left, right)
+		index /= 2
+
+		parent := t.layers[level+1]
+		if index < len(parent) {
+			parent[index] = node
+		} else {
+			t.layers[level+1] = append(parent, node)
+		}
+	}
+	return nil
+}
+
+// Root returns the current root, which is the root of the all-zero tree while
+// no leaf has been inserted.
+func (t *Tree) Root() *big.Int {
+	if t.Len() == 0 {
+		return t.zeros[t.levels]
+	}
+	return t.layers[t.levels][0]
+}
+
+// Proof returns the Merkle path of the leaf at index.
+func (t *Tree) Proof(index int) (*Proof, error) {
+	if index < 0 || index >= t.Len() {
+		return nil, fmt.Errorf("leaf index %d out of range", index)
+	}
+
+	proof := &Proof{
+		Root:         t.Root(),
+		PathElements: make([]*big.Int, t.levels),
+		PathIndices:  make([]int, t.levels),
+	}
+	for level := 0; level < t.levels; level++ {
+		sibling := index ^ 1
+		if sibling < len(t.layers[level]) {
+			proof.PathElements[level] = t.layers[level][sibling]
+		} else {
+			proof.PathElements[level] = t.zeros[level]
+		}
+		proof.PathIndices[level] = index % 2
+		index /= 2
+	}
+	return proof, nil
+}
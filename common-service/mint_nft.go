@@ -2,34 +2,76 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"math/big"
 	"os"
 	"os/signal"
-	"strings"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/ethereum/go-ethereum/accounts/abi"
+	"common-service/txmgr"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5"
 	"github.com/yourusername/yourrepo/db/sqlc"
 	"github.com/yourusername/yourrepo/mq/rabbitmq"
 )
 
 type MintMessage struct {
-	sqlc.KycInfo  `json:"kyc"`
+	CitizenID     string `json:"citizen_id"`
 	WalletAddress string `json:"wallet_address"`
 }
 
-const kycWalletNFTABI = `[{"inputs":[{"internalType":"address","name":"to","type":"address"}],"name":"mint","stateMutability":"nonpayable","type":"function"}]`
+// mintWorkerActor is the audit actor of the status changes made by the mint worker
+const mintWorkerActor = "mint-worker"
+
+// mintResumeInterval is how often unfinished mint jobs are resumed, e.g. ones
+// whose transaction was still pending when the wait timed out
+const mintResumeInterval = 5 * time.Minute
+
+// mintGuard keeps messages and resumed jobs from minting the same wallet
+// twice. Only claiming jobs and sending their transactions is serialised;
+// waiting for a receipt only holds the wallet, so a slow mint does not hold
+// up the others.
+type mintGuard struct {
+	// send is held from the status check of a wallet until its transaction
+	// is sent
+	send sync.Mutex
+
+	mu      sync.Mutex
+	minting map[string]bool
+}
+
+func newMintGuard() *mintGuard {
+	return &mintGuard{minting: make(map[string]bool)}
+}
 
-func StartMintWorker(repo *sqlc.Repository, rabbitmqURL string) {
+// claim marks a wallet as being minted. It returns false when another mint of
+// the wallet is still running.
+func (g *mintGuard) claim(wallet string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.minting[wallet] {
+		return false
+	}
+	g.minting[wallet] = true
+	return true
+}
+
+func (g *mintGuard) release(wallet string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.minting, wallet)
+}
+
+// StartMintWorker mints the NFTs of approved KYC records. Every wallet has a
+// mint job that records its transaction before it is sent, so a message that
+// is redelivered after a crash resumes the transaction instead of minting
+// again. Unfinished jobs are resumed on start and every mintResumeInterval.
+func StartMintWorker(repo *sqlc.Repository, contract *KycContract, rabbitmqURL string) {
 	log.Println("Starting mint worker...")
 	consumer, err := rabbitmq.NewConsumer(rabbitmqURL, "kyc-mint-exchange", "topic", "kyc-mint-queue", []string{"kyc.mint"})
 	if err != nil {
@@ -38,174 +80,292 @@ func StartMintWorker(repo *sqlc.Repository, rabbitmqURL string) {
 	}
 	defer consumer.Close()
 
-	log.Println("Consumer created successfully, waiting for messages...")
-
-	// Create a channel to handle graceful shutdown
-	done := make(chan struct{})
-
-	// Start consuming messages in a goroutine
+	guard := newMintGuard()
+	resumeMintJobs(repo, contract, guard)
 	go func() {
-		consumer.Consume(func(msg rabbitmq.MQMessage) {
-			log.Printf("Received message: %v", msg.Data)
-
-			// Convert map to JSON bytes
-			jsonData, err := json.Marshal(msg.Data)
-			if err != nil {
-				log.Printf("Failed to marshal message data: %v", err)
-				return
-			}
-
-			var mintMsg MintMessage
-			if err := json.Unmarshal(jsonData, &mintMsg); err != nil {
-				log.Printf("Failed to unmarshal message: %v", err)
-				return
-			}
-
-			log.Printf("Processing mint for wallet: %s", mintMsg.WalletAddress)
-			err = MintNFTForWallet(mintMsg.WalletAddress)
-			if err != nil {
-				log.Printf("Failed to mint NFT: %v", err)
-				return
-			}
-
-			// Only update KYC status if minting was successful
-			log.Println("NFT minted successfully, updating KYC status...")
-
-			// Get existing KYC info first
-			existingKYC, err := repo.GetKYCByCitizenID(context.Background(), mintMsg.CitizenID)
-			if err != nil {
-				log.Printf("Failed to get existing KYC info: %v", err)
-				return
-			}
-
-			// Update only the IsActive field
-			existingKYC.IsActive = pgtype.Bool{Bool: true, Valid: true}
-
-			err = repo.UpdateKYC(context.Background(), *existingKYC)
-			if err != nil {
-				log.Printf("Failed to update KYC status: %v", err)
-				return
-			}
-			log.Println("KYC status updated successfully")
-		})
+		ticker := time.NewTicker(mintResumeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			resumeMintJobs(repo, contract, guard)
+		}
 	}()
+	log.Println("Consumer created successfully, waiting for messages...")
+
+	// Mints that fail before their transaction is sent are not acked, so they
+	// are retried on redelivery; sent ones are followed by the resumption of
+	// unfinished jobs
+	err = consumer.ConsumeWithAck(func(msg rabbitmq.MQMessage) error {
+		var mintMsg MintMessage
+		if err := msg.Decode(&mintMsg); err != nil {
+			log.Printf("Failed to unmarshal message: %v", err)
+			return nil
+		}
+		ctx := sqlc.WithAudit(context.Background(), sqlc.Audit{Actor: mintWorkerActor})
+		return mintKYCWallet(ctx, repo, contract, guard, mintMsg.CitizenID, mintMsg.WalletAddress)
+	})
+	if err != nil {
+		log.Printf("Failed to consume messages: %v", err)
+		return
+	}
 
 	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	<-sigChan
-
-	// Signal the consumer to stop
-	close(done)
 	log.Println("Mint worker shutting down...")
 }
 
-func MintNFTForWallet(wallet string) error {
-	log.Printf("Starting mint process for wallet: %s", wallet)
+// resumeMintJobs follows up on the unfinished mint jobs, e.g. ones a previous
+// run left behind.
+func resumeMintJobs(repo *sqlc.Repository, contract *KycContract, guard *mintGuard) {
+	ctx := sqlc.WithAudit(context.Background(), sqlc.Audit{Actor: mintWorkerActor})
+	jobs, err := repo.ListUnfinishedMintJobs(ctx)
+	if err != nil {
+		log.Printf("Failed to list unfinished mint jobs: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		kyc, err := repo.GetKYCByWalletAddress(ctx, job.WalletAddress)
+		if errors.Is(err, pgx.ErrNoRows) {
+			abandonMintJob(ctx, repo, job.WalletAddress)
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to resume mint job of wallet %s: %v", job.WalletAddress, err)
+			continue
+		}
+		log.Printf("Resuming %s mint job of wallet %s", job.Status, job.WalletAddress)
+		if err := mintKYCWallet(ctx, repo, contract, guard, kyc.CitizenID, job.WalletAddress); err != nil {
+			log.Printf("Failed to resume mint job of wallet %s: %v", job.WalletAddress, err)
+		}
+	}
+}
 
-	// 1. Connect to Ethereum client
-	rpcURL := os.Getenv("RPC_URL")
-	if rpcURL == "" {
-		return fmt.Errorf("RPC_URL environment variable not set")
+// mintKYCWallet mints the NFT of a wallet of a KYC record. The first mint of
+// a record moves it through minting to active; wallets linked to an active
+// record only get their own NFT. Once the mint transaction is sent, its
+// receipt is awaited in the background and mintKYCWallet returns. An error
+// means the mint should be retried. A wallet that is already being minted is
+// left to that mint.
+func mintKYCWallet(ctx context.Context, repo *sqlc.Repository, contract *KycContract, guard *mintGuard, citizenID string, wallet string) error {
+	wallet = common.HexToAddress(wallet).Hex()
+	if !guard.claim(wallet) {
+		log.Printf("Skipping mint for wallet %s: it is already being minted", wallet)
+		return nil
+	}
+	// The wallet is released once its mint is over, by the wait if there is one
+	waiting := false
+	defer func() {
+		if !waiting {
+			guard.release(wallet)
+		}
+	}()
+	guard.send.Lock()
+	defer guard.send.Unlock()
+
+	// The wallet may have been unlinked since the mint was queued
+	kyc, err := repo.GetKYCByWalletAddress(ctx, wallet)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && kyc.CitizenID != citizenID) {
+		abandonMintJob(ctx, repo, wallet)
+		return nil
 	}
-	log.Printf("Connecting to RPC: %s", rpcURL)
-	client, err := ethclient.Dial(rpcURL)
 	if err != nil {
-		return fmt.Errorf("failed to connect to RPC: %v", err)
+		return err
 	}
-	defer client.Close()
 
-	// 2. Load private key
-	privateKeyStr := os.Getenv("PRIVATE_KEY")
-	if privateKeyStr == "" {
-		return fmt.Errorf("PRIVATE_KEY environment variable not set")
+	switch kyc.Status {
+	case sqlc.KycStatusActive, sqlc.KycStatusMinting:
+		// Records left in minting by a failed run carry on with their job
+	case sqlc.KycStatusApproved:
+		// Claim the record so a duplicate message does not mint twice
+		if _, err := repo.TransitionKYC(ctx, citizenID, sqlc.KycStatusMinting, ""); err != nil {
+			return err
+		}
+	default:
+		log.Printf("Skipping mint for wallet %s: KYC record is %s", wallet, kyc.Status)
+		return nil
 	}
-	log.Println("Loading private key...")
-	privateKey, err := crypto.HexToECDSA(privateKeyStr)
-	if err != nil {
-		return fmt.Errorf("failed to load private key: %v", err)
+
+	log.Printf("Processing mint for wallet: %s", wallet)
+	tx, status, err := mintWithJob(ctx, repo, contract, citizenID, wallet)
+	if err != nil || tx == nil {
+		return settleMint(ctx, repo, kyc, wallet, status, err)
 	}
 
-	// 3. Prepare contract interaction
-	chainID := big.NewInt(2021) // Ronin Saigon testnet chain ID
-	log.Println("Parsing ABI...")
-	parsedABI, err := abi.JSON(strings.NewReader(kycWalletNFTABI))
+	waiting = true
+	go func() {
+		defer guard.release(wallet)
+		status, err := waitMint(ctx, repo, contract, wallet, tx)
+		if err := settleMint(ctx, repo, kyc, wallet, status, err); err != nil {
+			log.Printf("Mint job of wallet %s is left %s: %v", wallet, status, err)
+		}
+	}()
+	return nil
+}
+
+// settleMint moves the KYC record of a wallet on after its mint job ended
+// in status, or failed with err. kyc is the record as it was before the mint.
+func settleMint(ctx context.Context, repo *sqlc.Repository, kyc *sqlc.KycInfo, wallet string, status string, err error) error {
+	if errors.Is(err, sqlc.ErrMintJobAbandoned) {
+		log.Printf("Skipping mint for wallet %s: the mint was abandoned for a burn", wallet)
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to parse ABI: %v", err)
+		log.Printf("Failed to mint NFT for wallet %s: %v", wallet, err)
+		// Without a transaction in flight, move the record back to approved so
+		// the mint can be retried
+		if status == sqlc.MintJobFailed && kyc.Status != sqlc.KycStatusActive {
+			reason := "mint failed: " + err.Error()
+			if _, err := repo.TransitionKYC(ctx, kyc.CitizenID, sqlc.KycStatusApproved, reason); err != nil {
+				log.Printf("Failed to update KYC status: %v", err)
+			}
+		}
+		return err
 	}
 
-	to := common.HexToAddress(wallet)
-	log.Printf("Preparing mint transaction for address: %s", to.Hex())
-	input, err := parsedABI.Pack("mint", to)
-	if err != nil {
-		return fmt.Errorf("failed to pack mint function: %v", err)
+	// Only activate the KYC record if minting was successful
+	if kyc.Status != sqlc.KycStatusActive {
+		log.Println("NFT minted successfully, updating KYC status...")
+		_, err := repo.TransitionKYC(ctx, kyc.CitizenID, sqlc.KycStatusActive, "")
+		var invalid *sqlc.InvalidKycTransitionError
+		if errors.As(err, &invalid) {
+			// The record was revoked during the mint, or another wallet's mint
+			// activated it; the revoke worker burns the NFT once the mint is
+			// finished
+			log.Printf("KYC record became %s during the mint of wallet %s", invalid.From, wallet)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		log.Println("KYC status updated successfully")
 	}
+	return nil
+}
 
-	// 4. Build transaction
-	contractAddress := common.HexToAddress(os.Getenv("KYC_ADDRESS"))
-	if contractAddress == (common.Address{}) {
-		return fmt.Errorf("KYC_ADDRESS environment variable not set or invalid")
+// mintWithJob mints the NFT of a wallet through its mint job. It returns the
+// transaction that is in flight, if any, and the status the job was left in.
+// A job that sent a transaction before returns that transaction instead of
+// sending a new one, and wallets that already hold an NFT, e.g. one kept
+// while their record was verified again, are done.
+func mintWithJob(ctx context.Context, repo *sqlc.Repository, contract *KycContract, citizenID string, wallet string) (*types.Transaction, string, error) {
+	job, err := repo.ClaimMintJob(ctx, citizenID, wallet)
+	if err != nil {
+		return nil, "", err
 	}
-	log.Printf("Contract address: %s", contractAddress.Hex())
 
-	// Get the sender's address
-	senderAddress := crypto.PubkeyToAddress(privateKey.PublicKey)
-	log.Printf("Sender address: %s", senderAddress.Hex())
+	if job.Status == sqlc.MintJobSent {
+		hash := common.HexToHash(job.TxHash.String)
+		tx, err := contract.Transaction(ctx, hash)
+		if err != nil {
+			return nil, job.Status, fmt.Errorf("failed to look up mint transaction %s: %v", hash.Hex(), err)
+		}
+		if tx != nil {
+			log.Printf("Waiting for mint transaction %s sent before", hash.Hex())
+			return tx, job.Status, nil
+		}
+		// The transaction never reached the node, e.g. because the process
+		// stopped before sending it
+		log.Printf("Mint transaction %s is unknown, minting again", hash.Hex())
+	}
 
-	nonce, err := client.PendingNonceAt(context.Background(), senderAddress)
+	balance, err := contract.BalanceOf(ctx, common.HexToAddress(wallet))
 	if err != nil {
-		return fmt.Errorf("failed to get nonce: %v", err)
+		status, err := failMintJob(ctx, repo, wallet, err)
+		return nil, status, err
+	}
+	if balance.Sign() > 0 {
+		log.Printf("Wallet %s already holds an NFT", wallet)
+		status, err := finishMintJob(ctx, repo, wallet, "", nil)
+		return nil, status, err
 	}
-	log.Printf("Current nonce: %d", nonce)
 
-	gasPrice, err := client.SuggestGasPrice(context.Background())
+	tx, err := contract.Build(ctx, "mint", common.HexToAddress(wallet))
 	if err != nil {
-		return fmt.Errorf("failed to get gas price: %v", err)
+		status, err := failMintJob(ctx, repo, wallet, err)
+		return nil, status, err
 	}
-	log.Printf("Gas price: %s", gasPrice.String())
-
-	tx := types.NewTransaction(
-		nonce,
-		contractAddress,
-		big.NewInt(0),   // value
-		uint64(200_000), // gas limit
-		gasPrice,        // gas price
-		input,
-	)
+	if err := repo.MarkMintJobSent(ctx, wallet, tx.Hash().Hex(), tx.Nonce()); err != nil {
+		// An abandoned job never sends its transaction; its nonce is filled
+		// as a gap by the next transaction
+		if errors.Is(err, sqlc.ErrMintJobAbandoned) {
+			return nil, sqlc.MintJobFailed, err
+		}
+		return nil, job.Status, err
+	}
+	if err := contract.Send(ctx, tx); err != nil {
+		// The transaction may still have been sent; the retry looks it up
+		if serr := repo.SetMintJobStatus(ctx, wallet, sqlc.MintJobSent, err.Error()); serr != nil {
+			log.Printf("Failed to update mint job: %v", serr)
+		}
+		return nil, sqlc.MintJobSent, err
+	}
+	return tx, sqlc.MintJobSent, nil
+}
 
-	log.Println("Signing transaction...")
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
+// waitMint waits for the outcome of a mint transaction and finishes its job.
+// A wait that times out leaves the job sent, so the retry waits again. When
+// the transaction was replaced by another one using its nonce, or dropped,
+// the balance of the wallet decides whether the mint happened.
+func waitMint(ctx context.Context, repo *sqlc.Repository, contract *KycContract, wallet string, tx *types.Transaction) (string, error) {
+	// Replacements with higher fees are recorded before they are sent, so
+	// the job can be resumed by the hash of the latest one
+	recordReplacement := func(replacement *types.Transaction) error {
+		return repo.MarkMintJobSent(ctx, wallet, replacement.Hash().Hex(), replacement.Nonce())
+	}
+	result, err := contract.Wait(ctx, tx, "mint", recordReplacement)
 	if err != nil {
-		return fmt.Errorf("failed to sign transaction: %v", err)
+		if serr := repo.SetMintJobStatus(ctx, wallet, sqlc.MintJobSent, err.Error()); serr != nil {
+			log.Printf("Failed to update mint job: %v", serr)
+		}
+		return sqlc.MintJobSent, err
 	}
 
-	// 5. Send transaction
-	log.Println("Sending transaction...")
-	err = client.SendTransaction(context.Background(), signedTx)
+	hash := result.Transaction.Hash().Hex()
+	switch result.Outcome {
+	case txmgr.OutcomeConfirmed:
+		return finishMintJob(ctx, repo, wallet, result.Outcome, nil)
+	case txmgr.OutcomeReverted:
+		return finishMintJob(ctx, repo, wallet, result.Outcome, fmt.Errorf("mint transaction %s reverted", hash))
+	}
+	balance, err := contract.BalanceOf(ctx, common.HexToAddress(wallet))
 	if err != nil {
-		return fmt.Errorf("failed to send transaction: %v", err)
+		// The job stays sent, and the retry finds the transaction unknown
+		return sqlc.MintJobSent, err
 	}
+	if balance.Sign() > 0 {
+		return finishMintJob(ctx, repo, wallet, result.Outcome, nil)
+	}
+	return finishMintJob(ctx, repo, wallet, result.Outcome, fmt.Errorf("mint transaction %s was %s", hash, result.Outcome))
+}
 
-	log.Printf("Transaction sent: %s", signedTx.Hash().Hex())
-
-	// 6. Wait for transaction confirmation
-	log.Println("Waiting for transaction confirmation...")
-	for {
-		receipt, err := client.TransactionReceipt(context.Background(), signedTx.Hash())
-		if err != nil {
-			// If receipt is not available yet, continue waiting
-			log.Println("Transaction pending...")
-			time.Sleep(3 * time.Second)
-			continue
+// finishMintJob marks a mint job succeeded, or failed when err is set, with
+// the outcome of its transaction.
+func finishMintJob(ctx context.Context, repo *sqlc.Repository, wallet string, outcome txmgr.Outcome, err error) (string, error) {
+	status, lastError := sqlc.MintJobSucceeded, ""
+	if err != nil {
+		status, lastError = sqlc.MintJobFailed, err.Error()
+	}
+	if ferr := repo.FinishMintJob(ctx, wallet, status, string(outcome), lastError); ferr != nil {
+		log.Printf("Failed to update mint job: %v", ferr)
+		if err == nil {
+			return status, ferr
 		}
+	}
+	return status, err
+}
 
-		// Transaction has been mined
-		if receipt.Status == 1 {
-			log.Println("Transaction SUCCESS: NFT minted")
-			return nil
-		} else {
-			log.Println("Transaction FAILED: Mint NFT failed")
-			return fmt.Errorf("transaction failed (revert). Check contract logic or parameters")
-		}
+func failMintJob(ctx context.Context, repo *sqlc.Repository, wallet string, err error) (string, error) {
+	if serr := repo.SetMintJobStatus(ctx, wallet, sqlc.MintJobFailed, err.Error()); serr != nil {
+		log.Printf("Failed to update mint job: %v", serr)
+	}
+	return sqlc.MintJobFailed, err
+}
+
+// abandonMintJob fails the mint job of a wallet that is no longer linked.
+func abandonMintJob(ctx context.Context, repo *sqlc.Repository, wallet string) {
+	log.Printf("Skipping mint for wallet %s: no longer linked to the KYC record", wallet)
+	if err := repo.SetMintJobStatus(ctx, wallet, sqlc.MintJobFailed, "wallet no longer linked"); err != nil {
+		log.Printf("Failed to update mint job: %v", err)
 	}
 }
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jackc/pgx/v5"
+	"github.com/yourusername/yourrepo/db/sqlc"
+	"github.com/yourusername/yourrepo/mq/rabbitmq"
+)
+
+type RevokeMessage struct {
+	CitizenID     string `json:"citizen_id"`
+	WalletAddress string `json:"wallet_address,omitempty"`
+}
+
+// revokeWorkerActor is the audit actor of the status changes made by the revoke worker
+const revokeWorkerActor = "revoke-worker"
+
+// revokeRetryInterval is how often revocations and burns that failed are retried
+const revokeRetryInterval = 5 * time.Minute
+
+// revokeBatchSize is the number of records and burn jobs retried per round
+const revokeBatchSize = 100
+
+// StartRevokeWorker burns the NFTs of revoked KYC records and of single
+// wallets, e.g. unlinked ones. A record only becomes revoked once the burn of
+// every one of its wallets is confirmed, and a single wallet burn is tracked
+// by a burn job. Failed revocations and burns are retried every
+// revokeRetryInterval.
+func StartRevokeWorker(repo *sqlc.Repository, contract *KycContract, rabbitmqURL string) {
+	log.Println("Starting revoke worker...")
+	consumer, err := rabbitmq.NewConsumer(rabbitmqURL, "kyc-mint-exchange", "topic", "kyc-revoke-queue", []string{"kyc.revoke"})
+	if err != nil {
+		log.Printf("Failed to create consumer: %v", err)
+		return
+	}
+	defer consumer.Close()
+
+	// Messages and retries burn the same wallets, so only one runs at a time
+	var mu sync.Mutex
+	go retryRevocations(context.Background(), &mu, repo, contract)
+
+	// Failed burns are not acked, so they are retried on redelivery
+	err = consumer.ConsumeWithAck(func(msg rabbitmq.MQMessage) error {
+		var revokeMsg RevokeMessage
+		if err := msg.Decode(&revokeMsg); err != nil {
+			log.Printf("Failed to unmarshal message: %v", err)
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		ctx := sqlc.WithAudit(context.Background(), sqlc.Audit{Actor: revokeWorkerActor})
+
+		// An unlinked wallet only loses its own NFT
+		if revokeMsg.WalletAddress != "" {
+			wallet := common.HexToAddress(revokeMsg.WalletAddress).Hex()
+			if err := repo.QueueBurnJob(ctx, revokeMsg.CitizenID, wallet); err != nil {
+				return err
+			}
+			return burnWallet(ctx, repo, contract, wallet)
+		}
+
+		if err := revokeKYC(ctx, repo, contract, revokeMsg.CitizenID); err != nil {
+			log.Printf("Failed to revoke KYC, it stays %s: %v", sqlc.KycStatusRevoking, err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to consume messages: %v", err)
+		return
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	<-sigChan
+	log.Println("Revoke worker shutting down...")
+}
+
+// retryRevocations retries the records left in revoking and the pending burn
+// jobs every revokeRetryInterval until ctx is cancelled.
+func retryRevocations(ctx context.Context, mu *sync.Mutex, repo *sqlc.Repository, contract *KycContract) {
+	ctx = sqlc.WithAudit(ctx, sqlc.Audit{Actor: revokeWorkerActor})
+	ticker := time.NewTicker(revokeRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		mu.Lock()
+		records, err := repo.ListKYCByStatus(ctx, sqlc.KycStatusRevoking, revokeBatchSize)
+		if err != nil {
+			log.Printf("Failed to list KYC records to revoke: %v", err)
+		}
+		for _, kyc := range records {
+			if err := revokeKYC(ctx, repo, contract, kyc.CitizenID); err != nil {
+				log.Printf("Failed to revoke KYC, it stays %s: %v", sqlc.KycStatusRevoking, err)
+			}
+		}
+
+		jobs, err := repo.ListPendingBurnJobs(ctx, revokeBatchSize)
+		if err != nil {
+			log.Printf("Failed to list pending burn jobs: %v", err)
+		}
+		for _, job := range jobs {
+			if err := burnWallet(ctx, repo, contract, job.WalletAddress); err != nil {
+				log.Printf("Failed to burn NFT of wallet %s: %v", job.WalletAddress, err)
+			}
+		}
+		mu.Unlock()
+	}
+}
+
+// burnWallet runs the burn job of a wallet. The job fails without a burn when
+// the wallet belongs to a record that should hold an NFT again, e.g. because
+// it was linked again since.
+func burnWallet(ctx context.Context, repo *sqlc.Repository, contract *KycContract, wallet string) error {
+	kyc, err := repo.GetKYCByWalletAddress(ctx, wallet)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if err == nil {
+		switch kyc.Status {
+		case sqlc.KycStatusApproved, sqlc.KycStatusMinting, sqlc.KycStatusActive, sqlc.KycStatusSuspended:
+			log.Printf("Skipping burn for wallet %s: KYC record is %s", wallet, kyc.Status)
+			return repo.SetBurnJobStatus(ctx, wallet, sqlc.BurnJobFailed, "wallet belongs to a "+kyc.Status+" KYC record")
+		}
+	}
+
+	err = stopMint(ctx, repo, wallet)
+	if err == nil {
+		err = RevokeNFTForWallet(ctx, contract, wallet)
+	}
+	if err != nil {
+		if serr := repo.SetBurnJobStatus(ctx, wallet, sqlc.BurnJobPending, err.Error()); serr != nil {
+			log.Printf("Failed to update burn job: %v", serr)
+		}
+		return err
+	}
+	return repo.SetBurnJobStatus(ctx, wallet, sqlc.BurnJobSucceeded, "")
+}
+
+// stopMint keeps a wallet from being minted while its NFT is burned. A mint
+// that did not send its transaction yet is abandoned; one whose transaction
+// is in flight has to finish first, so the burn fails and is retried later.
+func stopMint(ctx context.Context, repo *sqlc.Repository, wallet string) error {
+	abandoned, err := repo.AbandonMintJob(ctx, wallet, "NFT burn requested")
+	if err != nil || abandoned {
+		return err
+	}
+	job, err := repo.GetMintJob(ctx, wallet)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if job.Status == sqlc.MintJobSent {
+		return fmt.Errorf("mint transaction %s of %s is still in flight", job.TxHash.String, wallet)
+	}
+	return nil
+}
+
+// revokeKYC burns the NFTs of a record in revoking and marks it revoked.
+func revokeKYC(ctx context.Context, repo *sqlc.Repository, contract *KycContract, citizenID string) error {
+	kyc, err := repo.GetKYCByCitizenID(ctx, citizenID)
+	if err != nil {
+		return err
+	}
+	if kyc.Status != sqlc.KycStatusRevoking {
+		log.Printf("Skipping revoke of a KYC record in status %s", kyc.Status)
+		return nil
+	}
+
+	wallets, err := repo.GetWalletsByCitizenID(ctx, citizenID)
+	if err != nil {
+		return err
+	}
+	for _, wallet := range wallets {
+		if err := stopMint(ctx, repo, wallet); err != nil {
+			return err
+		}
+		if err := RevokeNFTForWallet(ctx, contract, wallet); err != nil {
+			return fmt.Errorf("failed to revoke NFT of %s: %v", wallet, err)
+		}
+	}
+
+	// Keep the reason the revocation was requested with
+	if _, err := repo.TransitionKYC(ctx, citizenID, sqlc.KycStatusRevoked, kyc.StatusReason.String); err != nil {
+		return err
+	}
+	log.Printf("KYC revoked, NFTs of %d wallets burned", len(wallets))
+	return nil
+}
+
+// RevokeNFTForWallet burns the NFT of a wallet. Wallets without an NFT, e.g.
+// because they were revoked before the mint, are skipped.
+func RevokeNFTForWallet(ctx context.Context, contract *KycContract, wallet string) error {
+	log.Printf("Starting revoke process for wallet: %s", wallet)
+	owner := common.HexToAddress(wallet)
+	balance, err := contract.BalanceOf(ctx, owner)
+	if err != nil {
+		return err
+	}
+	if balance.Sign() == 0 {
+		log.Printf("Wallet %s holds no NFT, nothing to revoke", wallet)
+		return nil
+	}
+	return contract.Call(ctx, "revoke", owner)
+}
@@ -0,0 +1,117 @@
+package screening
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Denylist is a named set of sanctioned addresses. Each address maps to the
+// description of its list entry, which may be empty.
+type Denylist struct {
+	Name    string
+	entries map[common.Address]string
+}
+
+// Len returns the number of addresses on the list.
+func (l *Denylist) Len() int {
+	return len(l.entries)
+}
+
+// Lookup returns the description of an address and whether it is listed.
+func (l *Denylist) Lookup(address common.Address) (string, bool) {
+	entry, ok := l.entries[address]
+	return entry, ok
+}
+
+// jsonEntry is an address of a JSON list given as an object
+type jsonEntry struct {
+	Address string `json:"address"`
+	Name    string `json:"name"`
+}
+
+// LoadDenylist reads a denylist from a .csv or .json file; the list is named
+// after the file. CSV files have the address in the first column and an
+// optional description in the second, lines starting with # are ignored.
+// JSON files hold an array of addresses or of {"address", "name"} objects.
+// Entries that are not EVM addresses, such as a CSV header, are skipped.
+func LoadDenylist(path string) (*Denylist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ext := strings.ToLower(filepath.Ext(path))
+	list := &Denylist{
+		Name:    strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		entries: make(map[common.Address]string),
+	}
+	switch ext {
+	case ".csv":
+		err = list.readCSV(file)
+	case ".json":
+		err = list.readJSON(file)
+	default:
+		return nil, fmt.Errorf("unsupported denylist format %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read denylist %s: %v", path, err)
+	}
+	return list, nil
+}
+
+func (l *Denylist) readCSV(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		entry := ""
+		if len(record) > 1 {
+			entry = record[1]
+		}
+		l.add(record[0], entry)
+	}
+}
+
+func (l *Denylist) readJSON(r io.Reader) error {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return err
+	}
+	for _, item := range raw {
+		var address string
+		if err := json.Unmarshal(item, &address); err == nil {
+			l.add(address, "")
+			continue
+		}
+		var entry jsonEntry
+		if err := json.Unmarshal(item, &entry); err != nil {
+			return fmt.Errorf("invalid entry %s", item)
+		}
+		l.add(entry.Address, entry.Name)
+	}
+	return nil
+}
+
+func (l *Denylist) add(address string, entry string) {
+	address = strings.TrimSpace(address)
+	if !common.IsHexAddress(address) {
+		return
+	}
+	l.entries[common.HexToAddress(address)] = strings.TrimSpace(entry)
+}
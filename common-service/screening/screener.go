@@ -0,0 +1,120 @@
+package screening
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Match is a denylist entry an address was found on.
+type Match struct {
+	List  string `json:"list"`
+	Entry string `json:"entry,omitempty"`
+}
+
+// Screener checks addresses against denylists loaded from files. The files
+// are reloaded when they change, see Watch.
+type Screener struct {
+	paths []string
+
+	mu       sync.RWMutex
+	lists    map[string]*Denylist
+	modTimes map[string]time.Time
+}
+
+// NewScreener loads the denylists at paths. Without paths no address matches.
+func NewScreener(paths []string) (*Screener, error) {
+	s := &Screener{
+		paths:    paths,
+		lists:    make(map[string]*Denylist),
+		modTimes: make(map[string]time.Time),
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		list, err := LoadDenylist(path)
+		if err != nil {
+			return nil, err
+		}
+		s.lists[path] = list
+		s.modTimes[path] = info.ModTime()
+		log.Printf("Loaded denylist %s with %d addresses", list.Name, list.Len())
+	}
+	return s, nil
+}
+
+// Check returns the denylist entries of an address.
+func (s *Screener) Check(address string) []Match {
+	if !common.IsHexAddress(address) {
+		return nil
+	}
+	addr := common.HexToAddress(address)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var matches []Match
+	for _, path := range s.paths {
+		list := s.lists[path]
+		if entry, ok := list.Lookup(addr); ok {
+			matches = append(matches, Match{List: list.Name, Entry: entry})
+		}
+	}
+	return matches
+}
+
+// Reload loads the denylists whose file changed since they were last loaded
+// and reports whether any did. A list that fails to load, e.g. because it is
+// being written, keeps its previous contents until the next reload.
+func (s *Screener) Reload() bool {
+	changed := false
+	for _, path := range s.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Printf("Failed to check denylist %s: %v", path, err)
+			continue
+		}
+		s.mu.RLock()
+		unchanged := info.ModTime().Equal(s.modTimes[path])
+		s.mu.RUnlock()
+		if unchanged {
+			continue
+		}
+
+		list, err := LoadDenylist(path)
+		if err != nil {
+			log.Printf("Keeping previous denylist: %v", err)
+			continue
+		}
+		s.mu.Lock()
+		s.lists[path] = list
+		s.modTimes[path] = info.ModTime()
+		s.mu.Unlock()
+		log.Printf("Reloaded denylist %s with %d addresses", list.Name, list.Len())
+		changed = true
+	}
+	return changed
+}
+
+// Watch reloads the changed denylists every interval until ctx is cancelled
+// and calls onReload after a reload changed a list.
+func (s *Screener) Watch(ctx context.Context, interval time.Duration, onReload func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if s.Reload() && onReload != nil {
+			onReload()
+		}
+	}
+}
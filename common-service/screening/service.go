@@ -0,0 +1,106 @@
+package screening
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/yourusername/yourrepo/db/sqlc"
+	"github.com/yourusername/yourrepo/mq/rabbitmq"
+)
+
+// Where a screened address was seen
+const (
+	SourceDeposit    = "deposit"
+	SourceWithdrawal = "withdrawal"
+	SourceKycWallet  = "kyc_wallet"
+)
+
+// walletBatchSize is the number of KYC wallets screened per query
+const walletBatchSize = 500
+
+// Subject is an address to screen with where it was seen. ChainID and TxHash
+// are set for deposits and withdrawals.
+type Subject struct {
+	Address string
+	Source  string
+	ChainID int32
+	TxHash  string
+}
+
+// Service screens addresses, stores the matches as flagged addresses and
+// raises an alert for every new match.
+type Service struct {
+	screener *Screener
+	repo     *sqlc.Repository
+	producer *rabbitmq.Producer
+}
+
+// NewService creates a new Service instance.
+func NewService(screener *Screener, repo *sqlc.Repository, producer *rabbitmq.Producer) *Service {
+	return &Service{
+		screener: screener,
+		repo:     repo,
+		producer: producer,
+	}
+}
+
+// Screen checks an address against the denylists and returns its matches.
+// Matches are stored and alerted on once per list and transaction.
+func (s *Service) Screen(ctx context.Context, subject Subject) ([]Match, error) {
+	matches := s.screener.Check(subject.Address)
+	for _, match := range matches {
+		created, err := s.repo.FlagAddress(ctx, sqlc.CreateFlaggedAddressParams{
+			Address:   subject.Address,
+			Source:    subject.Source,
+			ListName:  match.List,
+			ListEntry: pgtype.Text{String: match.Entry, Valid: match.Entry != ""},
+			ChainID:   pgtype.Int4{Int32: subject.ChainID, Valid: subject.ChainID != 0},
+			TxHash:    pgtype.Text{String: subject.TxHash, Valid: subject.TxHash != ""},
+		})
+		if err != nil {
+			return matches, fmt.Errorf("failed to flag address: %v", err)
+		}
+		if created {
+			s.alert(ctx, subject, match)
+		}
+	}
+	return matches, nil
+}
+
+// ScreenKYCWallets screens every wallet linked to a KYC record, e.g. after
+// a denylist changed.
+func (s *Service) ScreenKYCWallets(ctx context.Context) error {
+	after := ""
+	for {
+		wallets, err := s.repo.ListWalletAddresses(ctx, after, walletBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, wallet := range wallets {
+			if _, err := s.Screen(ctx, Subject{Address: wallet, Source: SourceKycWallet}); err != nil {
+				return err
+			}
+		}
+		if len(wallets) < walletBatchSize {
+			return nil
+		}
+		after = wallets[len(wallets)-1]
+	}
+}
+
+func (s *Service) alert(ctx context.Context, subject Subject, match Match) {
+	log.Printf("ALERT: %s address %s is on denylist %s (tx %s)", subject.Source, subject.Address, match.List, subject.TxHash)
+	event := rabbitmq.ScreeningAlertEvent{
+		Address:   subject.Address,
+		Source:    subject.Source,
+		ListName:  match.List,
+		ListEntry: match.Entry,
+		ChainID:   subject.ChainID,
+		TxHash:    subject.TxHash,
+	}
+	if err := s.producer.PublishStructContext(ctx, rabbitmq.RoutingKeyScreeningFlagged, event); err != nil {
+		log.Printf("Failed to publish screening alert for %s: %v", subject.Address, err)
+	}
+}
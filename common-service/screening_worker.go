@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"common-service/screening"
+
+	"github.com/yourusername/yourrepo/mq/rabbitmq"
+)
+
+// StartScreeningWorker screens the depositor of every new deposit and the
+// recipient of every new withdrawal published by the blockchain listener.
+func StartScreeningWorker(service *screening.Service, rabbitmqURL string) {
+	log.Println("Starting screening worker...")
+	consumer, err := rabbitmq.NewConsumer(rabbitmqURL, rabbitmq.BlockchainExchange, "topic", "screening-queue",
+		[]string{rabbitmq.RoutingKeyDeposit, rabbitmq.RoutingKeyWithdrawal})
+	if err != nil {
+		log.Printf("Failed to create consumer: %v", err)
+		return
+	}
+	defer consumer.Close()
+
+	// A failed screening is not acked, so the event is screened again on
+	// redelivery; flags are only created once per match
+	err = consumer.ConsumeWithAck(func(msg rabbitmq.MQMessage) error {
+		var subject screening.Subject
+		switch msg.Type {
+		case rabbitmq.RoutingKeyDeposit:
+			var deposit rabbitmq.DepositEvent
+			if err := msg.Decode(&deposit); err != nil {
+				log.Printf("Invalid deposit event: %v", err)
+				return nil
+			}
+			subject = screening.Subject{Address: deposit.Depositor, Source: screening.SourceDeposit, ChainID: deposit.ChainID, TxHash: deposit.TxHash}
+		case rabbitmq.RoutingKeyWithdrawal:
+			var withdrawal rabbitmq.WithdrawalEvent
+			if err := msg.Decode(&withdrawal); err != nil {
+				log.Printf("Invalid withdrawal event: %v", err)
+				return nil
+			}
+			subject = screening.Subject{Address: withdrawal.Recipient, Source: screening.SourceWithdrawal, ChainID: withdrawal.ChainID, TxHash: withdrawal.TxHash}
+		default:
+			return nil
+		}
+		if _, err := service.Screen(context.Background(), subject); err != nil {
+			log.Printf("Failed to screen %s %s: %v", subject.Source, subject.TxHash, err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to consume messages: %v", err)
+		return
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	<-sigChan
+	log.Println("Screening worker shutting down...")
+}
@@ -0,0 +1,145 @@
+// Package sigverify recovers and checks the wallet signatures sent to the KYC API.
+package sigverify
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Signature schemes accepted for a wallet binding
+const (
+	SchemePersonalSign = "personal_sign" // EIP-191 version 0x45
+	SchemeEIP712       = "eip712"
+)
+
+// MaxBindingValidity is how far in the future a binding may expire.
+const MaxBindingValidity = 15 * time.Minute
+
+// Binding is the statement a wallet signs to be linked to a citizen ID.
+type Binding struct {
+	WalletAddress common.Address
+	CitizenID     string
+	Nonce         string
+	ExpiresAt     time.Time
+}
+
+// Verifier checks wallet binding signatures.
+type Verifier struct {
+	// ChainID goes into the EIP-712 domain
+	ChainID int64
+}
+
+// NewVerifier creates a new Verifier instance.
+func NewVerifier(chainID int64) *Verifier {
+	return &Verifier{ChainID: chainID}
+}
+
+// Message returns the canonical personal_sign text of a binding.
+func (b Binding) Message() string {
+	return fmt.Sprintf("Cyclone KYC wallet binding\n"+
+		"Wallet: %s\n"+
+		"Citizen ID: %s\n"+
+		"Nonce: %s\n"+
+		"Expires At: %d",
+		b.WalletAddress.Hex(), b.CitizenID, b.Nonce, b.ExpiresAt.Unix())
+}
+
+// TypedData returns the EIP-712 form of a binding.
+func (v *Verifier) TypedData(b Binding) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+			},
+			"WalletBinding": {
+				{Name: "wallet", Type: "address"},
+				{Name: "citizenId", Type: "string"},
+				{Name: "nonce", Type: "string"},
+				{Name: "expiresAt", Type: "uint256"},
+			},
+		},
+		PrimaryType: "WalletBinding",
+		Domain: apitypes.TypedDataDomain{
+			Name:    "Cyclone KYC",
+			Version: "1",
+			ChainId: math.NewHexOrDecimal256(v.ChainID),
+		},
+		Message: apitypes.TypedDataMessage{
+			"wallet":    b.WalletAddress.Hex(),
+			"citizenId": b.CitizenID,
+			"nonce":     b.Nonce,
+			"expiresAt": new(big.Int).SetInt64(b.ExpiresAt.Unix()).String(),
+		},
+	}
+}
+
+// VerifyBinding checks that signature was made by the binding's wallet over
+// the binding using scheme, and that the binding has not expired.
+func (v *Verifier) VerifyBinding(b Binding, signature string, scheme string) error {
+	now := time.Now()
+	if !b.ExpiresAt.After(now) {
+		return fmt.Errorf("signature has expired")
+	}
+	if b.ExpiresAt.Sub(now) > MaxBindingValidity {
+		return fmt.Errorf("signature expiry is more than %s away", MaxBindingValidity)
+	}
+	if b.Nonce == "" || len(b.Nonce) > 100 {
+		return fmt.Errorf("invalid nonce")
+	}
+
+	var hash []byte
+	switch scheme {
+	case "", SchemePersonalSign:
+		hash = accounts.TextHash([]byte(b.Message()))
+	case SchemeEIP712:
+		digest, _, err := apitypes.TypedDataAndHash(v.TypedData(b))
+		if err != nil {
+			return fmt.Errorf("failed to hash typed data: %v", err)
+		}
+		hash = digest
+	default:
+		return fmt.Errorf("unsupported signature type %q", scheme)
+	}
+
+	signer, err := RecoverSigner(hash, signature)
+	if err != nil {
+		return err
+	}
+	if signer != b.WalletAddress {
+		return fmt.Errorf("signature was made by %s, not %s", signer.Hex(), b.WalletAddress.Hex())
+	}
+	return nil
+}
+
+// RecoverPersonalSign returns the address that personal_signed message.
+func RecoverPersonalSign(message string, signature string) (common.Address, error) {
+	return RecoverSigner(accounts.TextHash([]byte(message)), signature)
+}
+
+// RecoverSigner returns the address that produced a 65-byte [R || S || V]
+// signature over hash. V may be 0/1 or 27/28.
+func RecoverSigner(hash []byte, signature string) (common.Address, error) {
+	sig, err := hexutil.Decode(strings.TrimSpace(signature))
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid signature encoding")
+	}
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover signer: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
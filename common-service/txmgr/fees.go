@@ -0,0 +1,165 @@
+package txmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// replacementBump is the fee increase in percent a replacement needs over the
+// transaction it replaces. Nodes reject replacements bumped by less than 10%.
+const replacementBump = 10
+
+// ErrFeeCapReached is returned when a transaction cannot be replaced without
+// exceeding the configured fee caps.
+var ErrFeeCapReached = errors.New("fee cap reached")
+
+// FeeConfig configures the gas and fees of transactions.
+type FeeConfig struct {
+	// GasMargin is added to the gas estimate of a call, in percent
+	GasMargin uint64
+	// MaxFeePerGas caps the fee cap of dynamic fee transactions and the gas
+	// price of legacy ones; nil means no cap
+	MaxFeePerGas *big.Int
+	// MaxTipPerGas caps the priority fee of dynamic fee transactions; nil
+	// means no cap
+	MaxTipPerGas *big.Int
+	// BumpAfter is how long a transaction may stay pending before it is
+	// replaced with higher fees; zero disables bumping
+	BumpAfter time.Duration
+}
+
+// ParseFeeConfig parses a FeeConfig from its environment variables: the gas
+// margin in percent (default 20), the fee caps in gwei and the bump deadline
+// as a duration (default 3m). Empty values select the default.
+func ParseFeeConfig(gasMargin string, maxFeeGwei string, maxTipGwei string, bumpAfter string) (FeeConfig, error) {
+	config := FeeConfig{GasMargin: 20, BumpAfter: 3 * time.Minute}
+	var err error
+	if gasMargin != "" {
+		if config.GasMargin, err = strconv.ParseUint(gasMargin, 10, 64); err != nil {
+			return FeeConfig{}, fmt.Errorf("invalid gas margin %q", gasMargin)
+		}
+	}
+	if config.MaxFeePerGas, err = parseGwei(maxFeeGwei); err != nil {
+		return FeeConfig{}, err
+	}
+	if config.MaxTipPerGas, err = parseGwei(maxTipGwei); err != nil {
+		return FeeConfig{}, err
+	}
+	if bumpAfter != "" {
+		if config.BumpAfter, err = time.ParseDuration(bumpAfter); err != nil {
+			return FeeConfig{}, fmt.Errorf("invalid bump deadline %q", bumpAfter)
+		}
+	}
+	return config, nil
+}
+
+// parseGwei parses an amount of gwei, such as "1.5", into wei.
+func parseGwei(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	gwei, ok := new(big.Float).SetString(value)
+	if !ok || gwei.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid gwei amount %q", value)
+	}
+	wei, _ := gwei.Mul(gwei, big.NewFloat(params.GWei)).Int(nil)
+	return wei, nil
+}
+
+// fees are the fees of a transaction. GasTipCap is nil for legacy
+// transactions, whose gas price is GasFeeCap.
+type fees struct {
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+}
+
+// suggestFees returns the fees of a new transaction: dynamic fees on chains
+// with the London fork, a gas price on chains without a base fee.
+func (t *Transactor) suggestFees(ctx context.Context) (fees, error) {
+	head, err := t.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fees{}, fmt.Errorf("failed to get latest header: %v", err)
+	}
+	if head.BaseFee == nil {
+		gasPrice, err := t.client.SuggestGasPrice(ctx)
+		if err != nil {
+			return fees{}, fmt.Errorf("failed to get gas price: %v", err)
+		}
+		return fees{GasFeeCap: capFee(gasPrice, t.fees.MaxFeePerGas)}, nil
+	}
+
+	tip, err := t.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return fees{}, fmt.Errorf("failed to get gas tip: %v", err)
+	}
+	tip = capFee(tip, t.fees.MaxTipPerGas)
+	// Twice the base fee keeps the transaction includable through a run of
+	// full blocks
+	feeCap := new(big.Int).Mul(head.BaseFee, big.NewInt(2))
+	feeCap = capFee(feeCap.Add(feeCap, tip), t.fees.MaxFeePerGas)
+	if tip.Cmp(feeCap) > 0 {
+		tip = feeCap
+	}
+	if feeCap.Cmp(head.BaseFee) < 0 {
+		log.Printf("Fee cap %s is below the base fee %s, the transaction waits for the base fee to drop", feeCap, head.BaseFee)
+	}
+	return fees{GasFeeCap: feeCap, GasTipCap: tip}, nil
+}
+
+// bumpFees returns the fees of a replacement of tx, which keeps its type:
+// raised by replacementBump percent and at least the current suggestion.
+func (t *Transactor) bumpFees(ctx context.Context, tx *types.Transaction) (fees, error) {
+	if tx.Type() == types.LegacyTxType {
+		gasPrice := bumpFee(tx.GasPrice())
+		if suggested, err := t.client.SuggestGasPrice(ctx); err == nil && suggested.Cmp(gasPrice) > 0 {
+			gasPrice = suggested
+		}
+		if exceeds(gasPrice, t.fees.MaxFeePerGas) {
+			return fees{}, ErrFeeCapReached
+		}
+		return fees{GasFeeCap: gasPrice}, nil
+	}
+
+	bumped := fees{GasFeeCap: bumpFee(tx.GasFeeCap()), GasTipCap: bumpFee(tx.GasTipCap())}
+	if suggested, err := t.suggestFees(ctx); err == nil && suggested.GasTipCap != nil {
+		bumped.GasFeeCap = maxFee(bumped.GasFeeCap, suggested.GasFeeCap)
+		bumped.GasTipCap = maxFee(bumped.GasTipCap, suggested.GasTipCap)
+	}
+	if exceeds(bumped.GasFeeCap, t.fees.MaxFeePerGas) || exceeds(bumped.GasTipCap, t.fees.MaxTipPerGas) {
+		return fees{}, ErrFeeCapReached
+	}
+	return bumped, nil
+}
+
+// bumpFee raises a fee by replacementBump percent, rounded up.
+func bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+replacementBump))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+func capFee(fee *big.Int, limit *big.Int) *big.Int {
+	if exceeds(fee, limit) {
+		return new(big.Int).Set(limit)
+	}
+	return fee
+}
+
+func exceeds(fee *big.Int, limit *big.Int) bool {
+	return limit != nil && fee.Cmp(limit) > 0
+}
+
+func maxFee(a *big.Int, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,136 @@
+package txmgr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// maxNonceGaps bounds how far the stored nonce may be ahead of the chain. A
+// store further ahead is more likely misconfigured than behind on transactions.
+const maxNonceGaps = 64
+
+// NonceStore keeps the next nonce of accounts. Nonces must be reserved
+// atomically; *sqlc.Repository implements it on top of the database.
+type NonceStore interface {
+	// ReserveSignerNonce returns the next nonce of an account and advances
+	// it. The stored nonce is first raised to chainNonce, the pending nonce
+	// reported by the chain, so nonces used by other senders are skipped.
+	ReserveSignerNonce(ctx context.Context, chainID int64, address string, chainNonce uint64) (uint64, error)
+	// PeekSignerNonce returns the next nonce of an account without reserving
+	// it, or 0 when no nonce was reserved for it yet.
+	PeekSignerNonce(ctx context.Context, chainID int64, address string) (uint64, error)
+}
+
+// MemoryNonceStore is a NonceStore for a single process. Its nonces are
+// seeded from the chain again after a restart.
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]uint64
+}
+
+// NewMemoryNonceStore creates a new MemoryNonceStore instance.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]uint64)}
+}
+
+func (s *MemoryNonceStore) ReserveSignerNonce(ctx context.Context, chainID int64, address string, chainNonce uint64) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := nonceKey(chainID, address)
+	nonce := max(s.nonces[key], chainNonce)
+	s.nonces[key] = nonce + 1
+	return nonce, nil
+}
+
+func (s *MemoryNonceStore) PeekSignerNonce(ctx context.Context, chainID int64, address string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nonces[nonceKey(chainID, address)], nil
+}
+
+func nonceKey(chainID int64, address string) string {
+	return fmt.Sprintf("%d/%s", chainID, strings.ToLower(address))
+}
+
+// NonceReader reports the pending nonce of an account, as ethclient.Client does.
+type NonceReader interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceManager hands out the nonces of one account on one chain. Nonces that
+// were handed out but never reached the chain leave a gap that blocks every
+// later transaction of the account; Gap finds them.
+type NonceManager struct {
+	chain   NonceReader
+	store   NonceStore
+	chainID int64
+	account common.Address
+
+	mu sync.Mutex
+	// inFlight holds the nonces handed out and not sent yet, which are not gaps
+	inFlight map[uint64]bool
+}
+
+// NewNonceManager creates a new NonceManager instance.
+func NewNonceManager(chain NonceReader, store NonceStore, chainID int64, account common.Address) *NonceManager {
+	return &NonceManager{
+		chain:    chain,
+		store:    store,
+		chainID:  chainID,
+		account:  account,
+		inFlight: make(map[uint64]bool),
+	}
+}
+
+// Next reserves the next nonce of the account. Done must be called once the
+// transaction using it was sent or given up.
+func (m *NonceManager) Next(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pending, err := m.chain.PendingNonceAt(ctx, m.account)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending nonce: %v", err)
+	}
+	nonce, err := m.store.ReserveSignerNonce(ctx, m.chainID, m.account.Hex(), pending)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve nonce: %v", err)
+	}
+	m.inFlight[nonce] = true
+	return nonce, nil
+}
+
+// Done releases a nonce returned by Next.
+func (m *NonceManager) Done(nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.inFlight, nonce)
+}
+
+// Gap returns the nonce the chain waits for when it was handed out but never
+// sent, leaving out nonces still in flight. Nonces after it may be queued on
+// the node already, so gaps are found and filled one at a time. The account
+// must not be used by another process at the same time, whose in-flight
+// nonces would look like gaps.
+func (m *NonceManager) Gap(ctx context.Context) (uint64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pending, err := m.chain.PendingNonceAt(ctx, m.account)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get pending nonce: %v", err)
+	}
+	next, err := m.store.PeekSignerNonce(ctx, m.chainID, m.account.Hex())
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read nonce: %v", err)
+	}
+	if next > pending+maxNonceGaps {
+		return 0, false, fmt.Errorf("stored nonce %d is more than %d ahead of the chain nonce %d", next, maxNonceGaps, pending)
+	}
+	if pending < next && !m.inFlight[pending] {
+		return pending, true, nil
+	}
+	return 0, false, nil
+}
@@ -0,0 +1,118 @@
+package txmgr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// defaultSignMethod is the JSON-RPC method of Web3Signer and geth; Clef
+// serves account_signTransaction instead
+const defaultSignMethod = "eth_signTransaction"
+
+// RemoteSigner signs with a key held by a remote signer, such as Web3Signer
+// or Clef, over JSON-RPC. The signed transaction is checked against the one
+// that was sent for signing.
+type RemoteSigner struct {
+	client  *rpc.Client
+	method  string
+	address common.Address
+}
+
+// signTxArgs is the transaction passed to the remote signer.
+type signTxArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to,omitempty"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	Value                *hexutil.Big    `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Data                 hexutil.Bytes   `json:"data"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+}
+
+// NewRemoteSigner creates a new RemoteSigner instance for the account at
+// address. method defaults to eth_signTransaction.
+func NewRemoteSigner(ctx context.Context, url string, method string, address common.Address) (*RemoteSigner, error) {
+	if url == "" {
+		return nil, fmt.Errorf("no remote signer URL set")
+	}
+	if method == "" {
+		method = defaultSignMethod
+	}
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to remote signer: %v", err)
+	}
+	return &RemoteSigner{client: client, method: method, address: address}, nil
+}
+
+// Address returns the account of the remote signer.
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx has the remote signer sign tx.
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := signTxArgs{
+		From:    s.address,
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   (*hexutil.Big)(tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.Type() == types.LegacyTxType {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	} else {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	}
+
+	var result json.RawMessage
+	if err := s.client.CallContext(ctx, &result, s.method, args); err != nil {
+		return nil, fmt.Errorf("remote signer failed: %v", err)
+	}
+	raw, err := decodeSignResult(result)
+	if err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("remote signer returned an invalid transaction: %v", err)
+	}
+
+	signer := types.LatestSignerForChainID(chainID)
+	if signer.Hash(signed) != signer.Hash(tx) {
+		return nil, fmt.Errorf("remote signer changed the transaction")
+	}
+	if from, err := types.Sender(signer, signed); err != nil || from != s.address {
+		return nil, fmt.Errorf("remote signer did not sign for %s", s.address.Hex())
+	}
+	return signed, nil
+}
+
+// decodeSignResult returns the raw signed transaction of a result, either the
+// hex string of Web3Signer or the {"raw": ...} object of geth and Clef.
+func decodeSignResult(result json.RawMessage) ([]byte, error) {
+	var raw hexutil.Bytes
+	if err := json.Unmarshal(result, &raw); err == nil {
+		return raw, nil
+	}
+	var object struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := json.Unmarshal(result, &object); err != nil || len(object.Raw) == 0 {
+		return nil, fmt.Errorf("remote signer returned an invalid result")
+	}
+	return object.Raw, nil
+}
@@ -0,0 +1,152 @@
+package txmgr
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// stubSigner is a JSON-RPC signer serving eth_signTransaction with key. The
+// result is the raw transaction, or a {"raw": ...} object with rawObject set;
+// tamper, if set, changes the transaction before it is signed.
+func stubSigner(t *testing.T, key *ecdsa.PrivateKey, rawObject bool, tamper func(*types.DynamicFeeTx)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params []signTxArgs    `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != defaultSignMethod || len(req.Params) != 1 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		args := req.Params[0]
+		inner := &types.DynamicFeeTx{
+			ChainID:   args.ChainID.ToInt(),
+			Nonce:     uint64(args.Nonce),
+			GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+			GasFeeCap: args.MaxFeePerGas.ToInt(),
+			Gas:       uint64(args.Gas),
+			To:        args.To,
+			Value:     args.Value.ToInt(),
+			Data:      args.Data,
+		}
+		if tamper != nil {
+			tamper(inner)
+		}
+		signed, err := types.SignNewTx(key, types.LatestSignerForChainID(inner.ChainID), inner)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		raw, _ := signed.MarshalBinary()
+		var result interface{} = hexutil.Bytes(raw)
+		if rawObject {
+			result = map[string]interface{}{"raw": hexutil.Bytes(raw), "tx": signed}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+}
+
+func testTx(chainID *big.Int) *types.Transaction {
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     7,
+		GasTipCap: big.NewInt(1_000_000_000),
+		GasFeeCap: big.NewInt(30_000_000_000),
+		Gas:       60_000,
+		To:        &to,
+		Value:     big.NewInt(0),
+		Data:      []byte{0x6a, 0x62, 0x78, 0x4a},
+	})
+}
+
+func TestRemoteSignerSignTx(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	chainID := big.NewInt(2021)
+	tx := testTx(chainID)
+
+	tests := []struct {
+		name      string
+		rawObject bool
+	}{
+		{"hex result", false},
+		{"raw object result", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := stubSigner(t, key, tt.rawObject, nil)
+			defer server.Close()
+
+			signer, err := NewRemoteSigner(context.Background(), server.URL, "", address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signed, err := signer.SignTx(context.Background(), tx, chainID)
+			if err != nil {
+				t.Fatalf("SignTx: %v", err)
+			}
+			want, err := NewKeySigner(key).SignTx(context.Background(), tx, chainID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if signed.Hash() != want.Hash() {
+				t.Errorf("signed hash = %s, want %s", signed.Hash().Hex(), want.Hash().Hex())
+			}
+		})
+	}
+}
+
+func TestRemoteSignerRejectsBadSignatures(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	chainID := big.NewInt(2021)
+
+	tests := []struct {
+		name    string
+		key     *ecdsa.PrivateKey
+		tamper  func(*types.DynamicFeeTx)
+		wantErr string
+	}{
+		{"changed transaction", key, func(tx *types.DynamicFeeTx) { tx.Value = big.NewInt(1) }, "changed the transaction"},
+		{"wrong sender", other, nil, "did not sign for"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := stubSigner(t, tt.key, false, tt.tamper)
+			defer server.Close()
+
+			signer, err := NewRemoteSigner(context.Background(), server.URL, "", address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = signer.SignTx(context.Background(), testTx(chainID), chainID)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("SignTx error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package txmgr
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer backends selected by SignerConfig.Backend
+const (
+	SignerKey      = "key"
+	SignerKeystore = "keystore"
+	SignerRemote   = "remote"
+)
+
+// Signer signs the transactions of one account.
+type Signer interface {
+	// Address returns the account transactions are signed for.
+	Address() common.Address
+	// SignTx returns tx signed for chainID.
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// SignerConfig selects and configures a Signer.
+type SignerConfig struct {
+	// Backend is SignerKey, SignerKeystore or SignerRemote
+	Backend string
+	// PrivateKey is the hex key of SignerKey
+	PrivateKey string
+	// KeystoreFile and PasswordFile are the encrypted key of SignerKeystore
+	// and the file holding its password
+	KeystoreFile string
+	PasswordFile string
+	// URL, Method and Address configure SignerRemote
+	URL     string
+	Method  string
+	Address string
+}
+
+// LoadSigner creates the Signer selected by config. The raw key backend keeps
+// a plaintext key in the environment and is meant for development only.
+func LoadSigner(ctx context.Context, config SignerConfig) (Signer, error) {
+	switch config.Backend {
+	case SignerKey:
+		if config.PrivateKey == "" {
+			return nil, fmt.Errorf("no private key set")
+		}
+		key, err := crypto.HexToECDSA(config.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load private key: %v", err)
+		}
+		log.Println("WARNING: signing with a raw private key from the environment, which is for development only; use the keystore or remote signer in production")
+		return NewKeySigner(key), nil
+	case SignerKeystore:
+		return LoadKeystoreSigner(config.KeystoreFile, config.PasswordFile)
+	case SignerRemote:
+		if !common.IsHexAddress(config.Address) {
+			return nil, fmt.Errorf("invalid remote signer address %q", config.Address)
+		}
+		return NewRemoteSigner(ctx, config.URL, config.Method, common.HexToAddress(config.Address))
+	default:
+		return nil, fmt.Errorf("unknown signer %q", config.Backend)
+	}
+}
+
+// KeySigner signs with a private key held in memory.
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeySigner creates a new KeySigner instance.
+func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// LoadKeystoreSigner decrypts a go-ethereum keystore file with the password
+// read from passwordFile. The key is only ever held decrypted in memory.
+func LoadKeystoreSigner(keystoreFile string, passwordFile string) (*KeySigner, error) {
+	keyJSON, err := os.ReadFile(keystoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore file: %v", err)
+	}
+	password, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore password file: %v", err)
+	}
+	key, err := keystore.DecryptKey(keyJSON, strings.TrimRight(string(password), "\r\n"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore file: %v", err)
+	}
+	return NewKeySigner(key.PrivateKey), nil
+}
+
+// Address returns the address of the key.
+func (s *KeySigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx signs tx with the key.
+func (s *KeySigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
@@ -0,0 +1,183 @@
+package txmgr
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Transactor signs and sends the transactions of one account, taking its
+// nonces from a NonceManager. It sends EIP-1559 transactions on chains with
+// the London fork and legacy transactions elsewhere.
+type Transactor struct {
+	client  *ethclient.Client
+	signer  Signer
+	chainID *big.Int
+	fees    FeeConfig
+	wait    WaitConfig
+	nonces  *NonceManager
+}
+
+// NewTransactor creates a new Transactor instance for the account of signer.
+func NewTransactor(client *ethclient.Client, signer Signer, chainID *big.Int, store NonceStore, fees FeeConfig, wait WaitConfig) *Transactor {
+	return &Transactor{
+		client:  client,
+		signer:  signer,
+		chainID: chainID,
+		fees:    fees,
+		wait:    wait,
+		nonces:  NewNonceManager(client, store, chainID.Int64(), signer.Address()),
+	}
+}
+
+// Account returns the address transactions are sent from.
+func (t *Transactor) Account() common.Address {
+	return t.signer.Address()
+}
+
+// Client returns the client transactions are sent with.
+func (t *Transactor) Client() *ethclient.Client {
+	return t.client
+}
+
+// Build signs a contract call with the next nonce of the account, so its hash
+// can be recorded before it is sent. Nonce gaps are filled first, and calls
+// that would revert fail here without using a nonce. The transaction must be
+// passed to Send, or its nonce becomes a gap.
+func (t *Transactor) Build(ctx context.Context, to common.Address, data []byte) (*types.Transaction, error) {
+	if _, err := t.FillGaps(ctx); err != nil {
+		return nil, fmt.Errorf("failed to fill nonce gaps: %v", err)
+	}
+	gas, err := t.client.EstimateGas(ctx, ethereum.CallMsg{From: t.Account(), To: &to, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas: %v", err)
+	}
+	gas += gas * t.fees.GasMargin / 100
+	f, err := t.suggestFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := t.nonces.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := t.sign(ctx, t.newTx(nonce, to, big.NewInt(0), gas, data, f))
+	if err != nil {
+		t.nonces.Done(nonce)
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Send sends a transaction returned by Build.
+func (t *Transactor) Send(ctx context.Context, tx *types.Transaction) error {
+	defer t.nonces.Done(tx.Nonce())
+	if err := t.client.SendTransaction(ctx, tx); err != nil {
+		return fmt.Errorf("failed to send transaction: %v", err)
+	}
+	return nil
+}
+
+// Replace sends tx again with higher fees to unstick its nonce and returns
+// the replacement. Either of the two may still be mined.
+func (t *Transactor) Replace(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	return t.replace(ctx, tx, nil)
+}
+
+// replace is Replace with a callback that is called with the replacement
+// before it is sent and can cancel it by returning an error.
+func (t *Transactor) replace(ctx context.Context, tx *types.Transaction, onReplace func(*types.Transaction) error) (*types.Transaction, error) {
+	if tx.To() == nil {
+		return nil, fmt.Errorf("cannot replace contract creation %s", tx.Hash().Hex())
+	}
+	f, err := t.bumpFees(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := t.sign(ctx, t.newTx(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), tx.Data(), f))
+	if err != nil {
+		return nil, err
+	}
+	if onReplace != nil {
+		if err := onReplace(replacement); err != nil {
+			return nil, err
+		}
+	}
+	if err := t.client.SendTransaction(ctx, replacement); err != nil {
+		return nil, fmt.Errorf("failed to send replacement: %v", err)
+	}
+	log.Printf("Replaced transaction %s with %s at nonce %d", tx.Hash().Hex(), replacement.Hash().Hex(), tx.Nonce())
+	return replacement, nil
+}
+
+// FillGaps sends a transfer of nothing to the account itself for every nonce
+// that was handed out but never sent, which would otherwise block all later
+// transactions of the account. It returns the number of gaps filled.
+func (t *Transactor) FillGaps(ctx context.Context) (int, error) {
+	filled := 0
+	last, hasLast := uint64(0), false
+	for {
+		nonce, ok, err := t.nonces.Gap(ctx)
+		if err != nil || !ok {
+			return filled, err
+		}
+		if hasLast && nonce == last {
+			return filled, fmt.Errorf("nonce %d is still missing after filling it", nonce)
+		}
+
+		f, err := t.suggestFees(ctx)
+		if err != nil {
+			return filled, err
+		}
+		tx, err := t.sign(ctx, t.newTx(nonce, t.Account(), big.NewInt(0), params.TxGas, nil, f))
+		if err != nil {
+			return filled, err
+		}
+		if err := t.client.SendTransaction(ctx, tx); err != nil {
+			return filled, fmt.Errorf("failed to fill nonce %d: %v", nonce, err)
+		}
+		log.Printf("Filled nonce gap %d of %s with %s", nonce, t.Account().Hex(), tx.Hash().Hex())
+		filled++
+		last, hasLast = nonce, true
+	}
+}
+
+// newTx creates a dynamic fee transaction, or a legacy one when f has no tip.
+func (t *Transactor) newTx(nonce uint64, to common.Address, value *big.Int, gas uint64, data []byte, f fees) *types.Transaction {
+	if f.GasTipCap == nil {
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			To:       &to,
+			Value:    value,
+			Gas:      gas,
+			GasPrice: f.GasFeeCap,
+			Data:     data,
+		})
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   t.chainID,
+		Nonce:     nonce,
+		To:        &to,
+		Value:     value,
+		Gas:       gas,
+		GasFeeCap: f.GasFeeCap,
+		GasTipCap: f.GasTipCap,
+		Data:      data,
+	})
+}
+
+func (t *Transactor) sign(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	signed, err := t.signer.SignTx(ctx, tx, t.chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	return signed, nil
+}
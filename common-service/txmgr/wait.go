@@ -0,0 +1,285 @@
+package txmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// headPollInterval is how often the latest header is polled on nodes without
+// head subscriptions, e.g. over HTTP
+const headPollInterval = 3 * time.Second
+
+// Outcome is the terminal state of a transaction.
+type Outcome string
+
+const (
+	// OutcomeConfirmed means the transaction succeeded and has enough confirmations
+	OutcomeConfirmed Outcome = "confirmed"
+	// OutcomeReverted means the transaction was mined but reverted
+	OutcomeReverted Outcome = "reverted"
+	// OutcomeReplaced means another transaction used its nonce, e.g. a gap
+	// filler or a replacement sent by hand
+	OutcomeReplaced Outcome = "replaced"
+	// OutcomeDropped means the node no longer knows the transaction, its
+	// nonce is still unused and it could not be sent again
+	OutcomeDropped Outcome = "dropped"
+)
+
+// Result is the outcome of waiting for a transaction. Transaction is the one
+// that was mined, or the last one sent when none was.
+type Result struct {
+	Outcome     Outcome
+	Transaction *types.Transaction
+	Receipt     *types.Receipt
+}
+
+// WaitConfig configures how transactions are waited for.
+type WaitConfig struct {
+	// Confirmations is the number of blocks, including its own, a transaction
+	// needs to be final
+	Confirmations uint64
+	// Timeout bounds a wait; zero waits until the context is done
+	Timeout time.Duration
+	// DroppedAfter is the number of blocks a transaction may be unknown to the
+	// node before it is considered dropped. With fee bumping, an unknown
+	// transaction is first sent again with higher fees, and only one that
+	// cannot be bumped is dropped.
+	DroppedAfter uint64
+}
+
+// ParseWaitConfig parses a WaitConfig from its environment variables: the
+// confirmations (default 1), the timeout as a duration (default 10m) and the
+// blocks after which an unknown transaction is dropped (default 20). Empty
+// values select the default.
+func ParseWaitConfig(confirmations string, timeout string, droppedAfter string) (WaitConfig, error) {
+	config := WaitConfig{Confirmations: 1, Timeout: 10 * time.Minute, DroppedAfter: 20}
+	var err error
+	if confirmations != "" {
+		if config.Confirmations, err = strconv.ParseUint(confirmations, 10, 64); err != nil || config.Confirmations == 0 {
+			return WaitConfig{}, fmt.Errorf("invalid confirmations %q", confirmations)
+		}
+	}
+	if timeout != "" {
+		if config.Timeout, err = time.ParseDuration(timeout); err != nil {
+			return WaitConfig{}, fmt.Errorf("invalid wait timeout %q", timeout)
+		}
+	}
+	if droppedAfter != "" {
+		if config.DroppedAfter, err = strconv.ParseUint(droppedAfter, 10, 64); err != nil || config.DroppedAfter == 0 {
+			return WaitConfig{}, fmt.Errorf("invalid dropped after blocks %q", droppedAfter)
+		}
+	}
+	return config, nil
+}
+
+// Wait waits for tx or one of its replacements to reach a terminal outcome,
+// checking on every new head. A transaction still pending FeeConfig.BumpAfter
+// after it was sent is replaced with higher fees. onReplace, if set, is
+// called with every replacement before it is sent, e.g. to record its hash,
+// and can cancel the replacement by returning an error. An error means the
+// wait ended first, by its timeout or its context, and the transaction may
+// still be mined.
+func (t *Transactor) Wait(ctx context.Context, tx *types.Transaction, onReplace func(*types.Transaction) error) (*Result, error) {
+	var cancel context.CancelFunc
+	if t.wait.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.wait.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	w := &waiter{transactor: t, sent: []*types.Transaction{tx}, lastSent: time.Now(), onReplace: onReplace}
+	heads := t.watchHeads(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("transaction %s still pending: %w", w.latest().Hash().Hex(), ctx.Err())
+		case head := <-heads:
+			if result := w.check(ctx, head.Number.Uint64()); result != nil {
+				return result, nil
+			}
+		}
+	}
+}
+
+// waiter follows a transaction and its replacements from head to head.
+type waiter struct {
+	transactor *Transactor
+	sent       []*types.Transaction
+	lastSent   time.Time
+	onReplace  func(*types.Transaction) error
+
+	// Block numbers at which the nonce was first seen used by another
+	// transaction, and at which the node stopped knowing all of ours
+	nonceUsedAt  uint64
+	unknownSince uint64
+}
+
+func (w *waiter) latest() *types.Transaction {
+	return w.sent[len(w.sent)-1]
+}
+
+// check returns the outcome of the transaction at the given head, or nil while
+// it is not final. RPC errors are logged and retried at the next head.
+func (w *waiter) check(ctx context.Context, head uint64) *Result {
+	t := w.transactor
+	for _, candidate := range w.sent {
+		receipt, err := t.client.TransactionReceipt(ctx, candidate.Hash())
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to get receipt of %s: %v", candidate.Hash().Hex(), err)
+			return nil
+		}
+		// Receipts are read again at every head, so a reorg that drops the
+		// block starts the confirmations over
+		if head+1 < receipt.BlockNumber.Uint64()+t.wait.Confirmations {
+			return nil
+		}
+		outcome := OutcomeConfirmed
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			outcome = OutcomeReverted
+		}
+		return &Result{Outcome: outcome, Transaction: candidate, Receipt: receipt}
+	}
+
+	nonce, err := t.client.NonceAt(ctx, t.Account(), nil)
+	if err != nil {
+		log.Printf("Failed to get nonce of %s: %v", t.Account().Hex(), err)
+		return nil
+	}
+	if nonce > w.latest().Nonce() {
+		// Our receipt may only be indexed a block later, so another
+		// transaction is only blamed once the nonce is final
+		if w.nonceUsedAt == 0 {
+			w.nonceUsedAt = head
+		}
+		if head >= w.nonceUsedAt+t.wait.Confirmations {
+			return &Result{Outcome: OutcomeReplaced, Transaction: w.latest()}
+		}
+		return nil
+	}
+	w.nonceUsedAt = 0
+
+	if !w.known(ctx) {
+		if w.unknownSince == 0 {
+			w.unknownSince = head
+		}
+		// The node evicted the transaction, so it is sent again right away
+		if t.fees.BumpAfter > 0 && w.bump(ctx) {
+			return nil
+		}
+		if head >= w.unknownSince+t.wait.DroppedAfter {
+			return &Result{Outcome: OutcomeDropped, Transaction: w.latest()}
+		}
+		return nil
+	}
+	w.unknownSince = 0
+
+	if t.fees.BumpAfter > 0 && time.Since(w.lastSent) >= t.fees.BumpAfter {
+		w.bump(ctx)
+	}
+	return nil
+}
+
+// known reports whether the node knows any of the sent transactions.
+func (w *waiter) known(ctx context.Context) bool {
+	for _, candidate := range w.sent {
+		_, _, err := w.transactor.client.TransactionByHash(ctx, candidate.Hash())
+		if err == nil || !errors.Is(err, ethereum.NotFound) {
+			return true
+		}
+	}
+	return false
+}
+
+// bump replaces the latest transaction with higher fees and reports whether
+// the replacement was sent. A transaction the node no longer knows is sent
+// again that way before it is considered dropped.
+func (w *waiter) bump(ctx context.Context) bool {
+	latest := w.latest()
+	replacement, err := w.transactor.replace(ctx, latest, w.onReplace)
+	w.lastSent = time.Now()
+	switch {
+	case errors.Is(err, ErrFeeCapReached):
+		log.Printf("Transaction %s cannot be bumped: %v", latest.Hash().Hex(), err)
+		return false
+	case err != nil:
+		log.Printf("Failed to bump transaction %s: %v", latest.Hash().Hex(), err)
+		return false
+	}
+	w.sent = append(w.sent, replacement)
+	w.unknownSince = 0
+	return true
+}
+
+// watchHeads delivers the new heads of the chain until ctx is done, starting
+// with the current one. It subscribes to new heads and falls back to polling
+// when the node does not support subscriptions or the subscription fails.
+func (t *Transactor) watchHeads(ctx context.Context) <-chan *types.Header {
+	heads := make(chan *types.Header)
+	go func() {
+		var last uint64
+		deliver := func(head *types.Header) bool {
+			if head.Number.Uint64() <= last {
+				return true
+			}
+			last = head.Number.Uint64()
+			select {
+			case heads <- head:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		subscribed := make(chan *types.Header, 16)
+		sub, err := t.client.SubscribeNewHead(ctx, subscribed)
+		if err != nil {
+			sub = nil
+		} else {
+			defer sub.Unsubscribe()
+		}
+		ticker := time.NewTicker(headPollInterval)
+		defer ticker.Stop()
+		poll := true
+		for {
+			if poll {
+				if head, err := t.client.HeaderByNumber(ctx, nil); err == nil && !deliver(head) {
+					return
+				}
+			}
+			poll = false
+			select {
+			case <-ctx.Done():
+				return
+			case head := <-subscribed:
+				if !deliver(head) {
+					return
+				}
+			case err := <-subErr(sub):
+				log.Printf("Head subscription failed, polling instead: %v", err)
+				sub = nil
+			case <-ticker.C:
+				poll = sub == nil
+			}
+		}
+	}()
+	return heads
+}
+
+// subErr returns the error channel of a subscription, or nil without one.
+func subErr(sub ethereum.Subscription) <-chan error {
+	if sub == nil {
+		return nil
+	}
+	return sub.Err()
+}
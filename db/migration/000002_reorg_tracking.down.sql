@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS withdrawals_block_hash_index;
+DROP INDEX IF EXISTS withdrawals_chain_id_block_number_index;
+DROP INDEX IF EXISTS deposits_block_hash_index;
+DROP INDEX IF EXISTS deposits_chain_id_block_number_index;
+
+DROP TABLE IF EXISTS block_headers;
+
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS log_index;
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS block_hash;
+
+ALTER TABLE deposits DROP COLUMN IF EXISTS log_index;
+ALTER TABLE deposits DROP COLUMN IF EXISTS block_hash;
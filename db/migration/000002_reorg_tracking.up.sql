@@ -0,0 +1,20 @@
+ALTER TABLE deposits ADD COLUMN IF NOT EXISTS block_hash VARCHAR(255);
+ALTER TABLE deposits ADD COLUMN IF NOT EXISTS log_index INT;
+
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS block_hash VARCHAR(255);
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS log_index INT;
+
+-- Recent canonical headers seen by the listener, used to detect chain reorgs
+CREATE TABLE IF NOT EXISTS block_headers (
+    chain_id INT NOT NULL,
+    block_number INT NOT NULL,
+    block_hash VARCHAR(255) NOT NULL,
+    parent_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    PRIMARY KEY (chain_id, block_number)
+);
+
+CREATE INDEX IF NOT EXISTS deposits_chain_id_block_number_index ON deposits (chain_id, block_number);
+CREATE INDEX IF NOT EXISTS deposits_block_hash_index ON deposits (block_hash);
+CREATE INDEX IF NOT EXISTS withdrawals_chain_id_block_number_index ON withdrawals (chain_id, block_number);
+CREATE INDEX IF NOT EXISTS withdrawals_block_hash_index ON withdrawals (block_hash);
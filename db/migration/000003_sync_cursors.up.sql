@@ -0,0 +1,9 @@
+-- Last block fully ingested by the listener, per contract and event type
+CREATE TABLE IF NOT EXISTS sync_cursors (
+    chain_id INT NOT NULL,
+    contract_address VARCHAR(255) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    last_synced_block INT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    PRIMARY KEY (chain_id, contract_address, event_type)
+);
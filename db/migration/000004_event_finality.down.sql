@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS withdrawals_pending_index;
+DROP INDEX IF EXISTS deposits_pending_index;
+
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS confirmed;
+ALTER TABLE deposits DROP COLUMN IF EXISTS confirmed;
@@ -0,0 +1,10 @@
+-- Events stay pending until the listener has seen enough confirmations
+ALTER TABLE deposits ADD COLUMN IF NOT EXISTS confirmed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS confirmed BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Everything indexed before finality tracking is long settled
+UPDATE deposits SET confirmed = TRUE;
+UPDATE withdrawals SET confirmed = TRUE;
+
+CREATE INDEX IF NOT EXISTS deposits_pending_index ON deposits (chain_id, block_number) WHERE NOT confirmed;
+CREATE INDEX IF NOT EXISTS withdrawals_pending_index ON withdrawals (chain_id, block_number) WHERE NOT confirmed;
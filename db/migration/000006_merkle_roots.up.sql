@@ -0,0 +1,11 @@
+-- Root of each deposit tree right after the deposit at leaf_index was inserted
+CREATE TABLE IF NOT EXISTS merkle_roots (
+    chain_id INT NOT NULL,
+    contract_address VARCHAR(255) NOT NULL,
+    leaf_index INT NOT NULL,
+    root VARCHAR(66) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    PRIMARY KEY (chain_id, contract_address, leaf_index)
+);
+
+CREATE INDEX IF NOT EXISTS merkle_roots_root_index ON merkle_roots (root);
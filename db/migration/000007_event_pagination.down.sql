@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS deposits_page_index;
+DROP INDEX IF EXISTS withdrawals_page_index;
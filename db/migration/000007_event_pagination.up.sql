@@ -0,0 +1,4 @@
+-- Keyset pagination of the event endpoints walks (block_number, log_index);
+-- events indexed before log_index was tracked sort first within their block
+CREATE INDEX IF NOT EXISTS deposits_page_index ON deposits (chain_id, contract_address, block_number, (COALESCE(log_index, -1)));
+CREATE INDEX IF NOT EXISTS withdrawals_page_index ON withdrawals (chain_id, contract_address, block_number, (COALESCE(log_index, -1)));
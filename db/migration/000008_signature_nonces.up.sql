@@ -0,0 +1,12 @@
+-- Nonces of wallet signatures already accepted, so a signed binding cannot be replayed
+CREATE TABLE IF NOT EXISTS signature_nonces (
+    wallet_address VARCHAR(255) NOT NULL,
+    nonce VARCHAR(100) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    PRIMARY KEY (wallet_address, nonce)
+);
+
+CREATE INDEX IF NOT EXISTS signature_nonces_expires_at_index ON signature_nonces (expires_at);
+
+COMMENT ON COLUMN wallet_info.wallet_signature IS 'EIP-191 or EIP-712 signature of the wallet binding message (citizen_id, nonce, expiry)';
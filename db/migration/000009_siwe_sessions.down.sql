@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS wallet_info_lower_wallet_address_index;
+DROP TABLE IF EXISTS siwe_nonces;
@@ -0,0 +1,11 @@
+-- Nonces handed out for Sign-In-With-Ethereum logins; a nonce is deleted when it is used
+CREATE TABLE IF NOT EXISTS siwe_nonces (
+    nonce VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS siwe_nonces_expires_at_index ON siwe_nonces (expires_at);
+
+-- Session wallets are checksummed while older rows may be stored in any case
+CREATE INDEX IF NOT EXISTS wallet_info_lower_wallet_address_index ON wallet_info (LOWER(wallet_address));
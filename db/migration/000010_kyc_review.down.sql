@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS wallet_info_citizen_id_index;
+DROP INDEX IF EXISTS kyc_info_review_status_index;
+
+ALTER TABLE kyc_info
+    DROP CONSTRAINT IF EXISTS kyc_info_review_status_check,
+    DROP COLUMN IF EXISTS review_status,
+    DROP COLUMN IF EXISTS review_reason,
+    DROP COLUMN IF EXISTS reviewed_by,
+    DROP COLUMN IF EXISTS reviewed_at,
+    DROP COLUMN IF EXISTS submitted_at;
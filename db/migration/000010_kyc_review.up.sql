@@ -0,0 +1,16 @@
+-- Submissions are reviewed by a verifier before the KYC NFT is minted
+ALTER TABLE kyc_info
+    ADD COLUMN IF NOT EXISTS review_status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    ADD COLUMN IF NOT EXISTS review_reason TEXT,
+    ADD COLUMN IF NOT EXISTS reviewed_by VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS submitted_at TIMESTAMP NOT NULL DEFAULT (now());
+
+ALTER TABLE kyc_info
+    ADD CONSTRAINT kyc_info_review_status_check CHECK (review_status IN ('pending', 'approved', 'rejected'));
+
+-- Records minted before reviews existed count as approved
+UPDATE kyc_info SET review_status = 'approved', submitted_at = kyc_verified_at WHERE is_active;
+
+CREATE INDEX IF NOT EXISTS kyc_info_review_status_index ON kyc_info (review_status, submitted_at);
+CREATE INDEX IF NOT EXISTS wallet_info_citizen_id_index ON wallet_info (citizen_id);
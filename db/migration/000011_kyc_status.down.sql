@@ -0,0 +1,33 @@
+DROP INDEX IF EXISTS kyc_info_status_index;
+
+ALTER TABLE kyc_info
+    ADD COLUMN IF NOT EXISTS is_active BOOLEAN,
+    ADD COLUMN IF NOT EXISTS review_status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;
+
+UPDATE kyc_info SET
+    is_active = status = 'active',
+    review_status = CASE
+        WHEN status IN ('approved', 'minting', 'active', 'suspended', 'revoked', 'expired') THEN 'approved'
+        WHEN status = 'rejected' THEN 'rejected'
+        ELSE 'pending'
+    END,
+    reviewed_at = COALESCE(approved_at, rejected_at);
+
+ALTER TABLE kyc_info
+    ADD CONSTRAINT kyc_info_review_status_check CHECK (review_status IN ('pending', 'approved', 'rejected'));
+CREATE INDEX IF NOT EXISTS kyc_info_review_status_index ON kyc_info (review_status, submitted_at);
+
+ALTER TABLE kyc_info
+    DROP CONSTRAINT IF EXISTS kyc_info_status_check,
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS under_review_at,
+    DROP COLUMN IF EXISTS approved_at,
+    DROP COLUMN IF EXISTS rejected_at,
+    DROP COLUMN IF EXISTS minting_at,
+    DROP COLUMN IF EXISTS active_at,
+    DROP COLUMN IF EXISTS suspended_at,
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS expired_at;
+
+ALTER TABLE kyc_info RENAME COLUMN status_reason TO review_reason;
@@ -0,0 +1,37 @@
+-- The lifecycle of a KYC record is tracked by its status, which replaces
+-- is_active and review_status. Every status records when it was last entered.
+ALTER TABLE kyc_info RENAME COLUMN review_reason TO status_reason;
+
+ALTER TABLE kyc_info
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'submitted',
+    ADD COLUMN IF NOT EXISTS under_review_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS approved_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS rejected_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS minting_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS active_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS expired_at TIMESTAMP;
+
+UPDATE kyc_info SET
+    status = CASE
+        WHEN is_active THEN 'active'
+        WHEN review_status = 'approved' THEN 'approved'
+        WHEN review_status = 'rejected' THEN 'rejected'
+        ELSE 'submitted'
+    END,
+    approved_at = CASE WHEN review_status = 'approved' THEN COALESCE(reviewed_at, kyc_verified_at) END,
+    rejected_at = CASE WHEN review_status = 'rejected' THEN reviewed_at END,
+    active_at = CASE WHEN is_active THEN kyc_verified_at END;
+
+ALTER TABLE kyc_info
+    ADD CONSTRAINT kyc_info_status_check CHECK (status IN ('submitted', 'under_review', 'approved', 'rejected', 'minting', 'active', 'suspended', 'revoked', 'expired'));
+
+DROP INDEX IF EXISTS kyc_info_review_status_index;
+ALTER TABLE kyc_info
+    DROP CONSTRAINT IF EXISTS kyc_info_review_status_check,
+    DROP COLUMN IF EXISTS is_active,
+    DROP COLUMN IF EXISTS review_status,
+    DROP COLUMN IF EXISTS reviewed_at;
+
+CREATE INDEX IF NOT EXISTS kyc_info_status_index ON kyc_info (status, submitted_at);
@@ -0,0 +1,14 @@
+-- Audit trail of every change to a KYC record, written in the transaction of the change
+CREATE TABLE IF NOT EXISTS kyc_events (
+    id BIGSERIAL PRIMARY KEY,
+    citizen_id VARCHAR(255) NOT NULL,
+    actor VARCHAR(255),
+    action VARCHAR(50) NOT NULL,
+    -- Changed fields as {"field": {"before": ..., "after": ...}}; personal data is redacted
+    changes JSONB NOT NULL,
+    request_id VARCHAR(100),
+    source_ip VARCHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS kyc_events_citizen_id_index ON kyc_events (citizen_id, id);
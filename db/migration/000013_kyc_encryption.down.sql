@@ -0,0 +1,15 @@
+-- Encrypted rows have to be decrypted before migrating down, their personal
+-- data is lost otherwise
+ALTER TABLE wallet_info DROP CONSTRAINT IF EXISTS wallet_info_citizen_id_fkey;
+ALTER TABLE wallet_info
+    ADD CONSTRAINT wallet_info_citizen_id_fkey FOREIGN KEY (citizen_id) REFERENCES kyc_info(citizen_id);
+
+DROP INDEX IF EXISTS kyc_info_key_id_index;
+
+ALTER TABLE kyc_info
+    DROP COLUMN IF EXISTS citizen_id_ciphertext,
+    DROP COLUMN IF EXISTS full_name_ciphertext,
+    DROP COLUMN IF EXISTS phone_number_ciphertext,
+    DROP COLUMN IF EXISTS date_of_birth_ciphertext,
+    DROP COLUMN IF EXISTS data_key_ciphertext,
+    DROP COLUMN IF EXISTS key_id;
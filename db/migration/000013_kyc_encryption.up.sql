@@ -0,0 +1,20 @@
+-- Personal data is envelope encrypted by the repository: every record has its
+-- own data key, stored wrapped by the KMS key key_id. citizen_id holds a keyed
+-- blind index (HMAC-SHA256) of the citizen ID so lookups keep working.
+--
+-- Existing rows stay in plaintext until `kyc-keys encrypt` is run, which moves
+-- them to the encrypted columns and replaces their citizen_id by the blind index.
+ALTER TABLE kyc_info
+    ADD COLUMN IF NOT EXISTS citizen_id_ciphertext BYTEA,
+    ADD COLUMN IF NOT EXISTS full_name_ciphertext BYTEA,
+    ADD COLUMN IF NOT EXISTS phone_number_ciphertext BYTEA,
+    ADD COLUMN IF NOT EXISTS date_of_birth_ciphertext BYTEA,
+    ADD COLUMN IF NOT EXISTS data_key_ciphertext BYTEA,
+    ADD COLUMN IF NOT EXISTS key_id VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS kyc_info_key_id_index ON kyc_info (key_id);
+
+-- Wallets follow their record when its citizen_id is replaced by the blind index
+ALTER TABLE wallet_info DROP CONSTRAINT IF EXISTS wallet_info_citizen_id_fkey;
+ALTER TABLE wallet_info
+    ADD CONSTRAINT wallet_info_citizen_id_fkey FOREIGN KEY (citizen_id) REFERENCES kyc_info(citizen_id) ON UPDATE CASCADE;
@@ -0,0 +1,7 @@
+UPDATE kyc_info SET status = 'revoked', revoked_at = revoking_at WHERE status = 'revoking';
+
+ALTER TABLE kyc_info DROP CONSTRAINT IF EXISTS kyc_info_status_check;
+ALTER TABLE kyc_info
+    ADD CONSTRAINT kyc_info_status_check CHECK (status IN ('submitted', 'under_review', 'approved', 'rejected', 'minting', 'active', 'suspended', 'revoked', 'expired'));
+
+ALTER TABLE kyc_info DROP COLUMN IF EXISTS revoking_at;
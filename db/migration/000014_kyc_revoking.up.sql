@@ -0,0 +1,6 @@
+-- A revoked record waits in revoking until the burn of its NFT is confirmed
+ALTER TABLE kyc_info ADD COLUMN IF NOT EXISTS revoking_at TIMESTAMP;
+
+ALTER TABLE kyc_info DROP CONSTRAINT IF EXISTS kyc_info_status_check;
+ALTER TABLE kyc_info
+    ADD CONSTRAINT kyc_info_status_check CHECK (status IN ('submitted', 'under_review', 'approved', 'rejected', 'minting', 'active', 'suspended', 'revoking', 'revoked', 'expired'));
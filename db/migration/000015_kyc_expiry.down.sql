@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS kyc_info_expires_at_index;
+
+ALTER TABLE kyc_info
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS expiry_notified_at;
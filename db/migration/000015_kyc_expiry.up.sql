@@ -0,0 +1,8 @@
+-- A verification is valid for a period that depends on the nationality or the
+-- verifier of the record. expires_at is set by the expiry scheduler after the
+-- approval; expiry_notified_at records when the holder was told it is due.
+ALTER TABLE kyc_info
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS expiry_notified_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS kyc_info_expires_at_index ON kyc_info (expires_at);
@@ -0,0 +1,17 @@
+-- Addresses that matched a sanctions denylist, with where they were seen.
+-- chain_id and tx_hash are set for deposits and withdrawals.
+CREATE TABLE IF NOT EXISTS flagged_addresses (
+    id BIGSERIAL PRIMARY KEY,
+    address VARCHAR(42) NOT NULL,
+    source VARCHAR(20) NOT NULL CHECK (source IN ('deposit', 'withdrawal', 'kyc_wallet')),
+    list_name VARCHAR(100) NOT NULL,
+    list_entry TEXT,
+    chain_id INT,
+    tx_hash VARCHAR(66),
+    created_at TIMESTAMP NOT NULL DEFAULT (now())
+);
+
+-- A match is stored (and alerted on) once per list and transaction
+CREATE UNIQUE INDEX IF NOT EXISTS flagged_addresses_match_index
+    ON flagged_addresses (LOWER(address), source, list_name, COALESCE(tx_hash, ''));
+CREATE INDEX IF NOT EXISTS flagged_addresses_address_index ON flagged_addresses (LOWER(address), id);
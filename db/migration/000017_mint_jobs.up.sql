@@ -0,0 +1,17 @@
+-- One NFT mint per wallet. tx_hash and nonce are stored before the
+-- transaction is sent, so a restarted worker can follow up on it instead of
+-- minting again. citizen_id holds the blind index of the record.
+CREATE TABLE IF NOT EXISTS mint_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    wallet_address VARCHAR(42) NOT NULL UNIQUE,
+    citizen_id VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'succeeded', 'failed')),
+    tx_hash VARCHAR(66),
+    nonce BIGINT,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS mint_jobs_status_index ON mint_jobs (status);
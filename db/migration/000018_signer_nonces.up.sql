@@ -0,0 +1,9 @@
+-- Next nonce of every account the services send transactions from, so
+-- concurrent and restarted senders never hand out the same nonce twice
+CREATE TABLE IF NOT EXISTS signer_nonces (
+    chain_id BIGINT NOT NULL,
+    address VARCHAR(42) NOT NULL,
+    next_nonce BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    PRIMARY KEY (chain_id, address)
+);
@@ -0,0 +1 @@
+ALTER TABLE mint_jobs DROP COLUMN IF EXISTS outcome;
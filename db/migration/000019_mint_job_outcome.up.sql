@@ -0,0 +1,4 @@
+-- Terminal outcome of the last transaction of a finished mint job: confirmed,
+-- reverted, replaced by another transaction with its nonce, or dropped by the node
+ALTER TABLE mint_jobs ADD COLUMN IF NOT EXISTS outcome VARCHAR(20)
+    CHECK (outcome IN ('confirmed', 'reverted', 'replaced', 'dropped'));
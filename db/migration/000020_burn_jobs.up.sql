@@ -0,0 +1,15 @@
+-- One NFT burn per wallet for burns outside of a record revocation, i.e. of
+-- unlinked wallets and expired records. Pending jobs are retried until the
+-- burn is confirmed. citizen_id holds the blind index of the record.
+CREATE TABLE IF NOT EXISTS burn_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    wallet_address VARCHAR(42) NOT NULL UNIQUE,
+    citizen_id VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS burn_jobs_status_index ON burn_jobs (status);
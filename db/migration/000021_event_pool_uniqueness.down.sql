@@ -0,0 +1,5 @@
+ALTER TABLE withdrawals DROP CONSTRAINT IF EXISTS withdrawals_pool_nullifier_hash_key;
+ALTER TABLE withdrawals ADD CONSTRAINT withdrawals_nullifier_hash_key UNIQUE (nullifier_hash);
+
+ALTER TABLE deposits DROP CONSTRAINT IF EXISTS deposits_pool_commitment_key;
+ALTER TABLE deposits ADD CONSTRAINT deposits_commitment_key UNIQUE (commitment);
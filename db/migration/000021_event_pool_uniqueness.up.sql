@@ -0,0 +1,7 @@
+-- Commitments and nullifier hashes are only unique within one pool; the same
+-- value on another chain or contract is a different event
+ALTER TABLE deposits DROP CONSTRAINT IF EXISTS deposits_commitment_key;
+ALTER TABLE deposits ADD CONSTRAINT deposits_pool_commitment_key UNIQUE (chain_id, contract_address, commitment);
+
+ALTER TABLE withdrawals DROP CONSTRAINT IF EXISTS withdrawals_nullifier_hash_key;
+ALTER TABLE withdrawals ADD CONSTRAINT withdrawals_pool_nullifier_hash_key UNIQUE (chain_id, contract_address, nullifier_hash);
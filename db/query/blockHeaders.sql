@@ -0,0 +1,28 @@
+-- name: UpsertBlockHeader :exec
+INSERT INTO block_headers (chain_id, block_number, block_hash, parent_hash)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (chain_id, block_number) DO UPDATE
+SET block_hash = EXCLUDED.block_hash,
+    parent_hash = EXCLUDED.parent_hash,
+    created_at = now();
+
+-- name: GetBlockHeader :one
+SELECT * FROM block_headers
+WHERE chain_id = $1
+AND block_number = $2;
+
+-- name: GetLatestBlockHeader :one
+SELECT * FROM block_headers
+WHERE chain_id = $1
+ORDER BY block_number DESC
+LIMIT 1;
+
+-- name: DeleteBlockHeadersAfter :exec
+DELETE FROM block_headers
+WHERE chain_id = $1
+AND block_number > $2;
+
+-- name: PruneBlockHeaders :exec
+DELETE FROM block_headers
+WHERE chain_id = $1
+AND block_number < $2;
@@ -0,0 +1,17 @@
+-- name: ListPendingBurnJobs :many
+SELECT * FROM burn_jobs
+WHERE status = 'pending'
+ORDER BY id
+LIMIT $1;
+
+-- name: QueueBurnJob :exec
+-- A wallet that was burned before starts over.
+INSERT INTO burn_jobs (wallet_address, citizen_id)
+VALUES ($1, $2)
+ON CONFLICT (wallet_address) DO UPDATE
+SET citizen_id = EXCLUDED.citizen_id, status = 'pending', last_error = NULL, updated_at = now();
+
+-- name: SetBurnJobStatus :exec
+UPDATE burn_jobs
+SET status = $2, last_error = $3, attempts = attempts + 1, updated_at = now()
+WHERE wallet_address = $1;
@@ -1,16 +1,29 @@
 
 -- name: CreateDeposit :one
-INSERT INTO deposits (contract_address, commitment, depositor, leaf_index, tx_hash, timestamp, block_number, chain_id) 
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-ON CONFLICT (commitment) DO NOTHING
+INSERT INTO deposits (contract_address, commitment, depositor, leaf_index, tx_hash, timestamp, block_number, chain_id, block_hash, log_index) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (chain_id, contract_address, commitment) DO NOTHING
 RETURNING *;
 
--- name: GetDepositsFromBlockToBlock :many
-SELECT * FROM deposits 
-WHERE contract_address = $1
-AND chain_id = $2
-AND block_number BETWEEN $3 AND $4
-ORDER BY block_number DESC;
+-- name: GetDepositsPageAsc :many
+SELECT * FROM deposits
+WHERE contract_address = sqlc.arg(contract_address)
+AND chain_id = sqlc.arg(chain_id)
+AND block_number BETWEEN sqlc.arg(from_block) AND sqlc.arg(to_block)
+AND (confirmed OR NOT sqlc.arg(confirmed_only)::boolean)
+AND (block_number, COALESCE(log_index, -1)) > (sqlc.arg(cursor_block)::int, sqlc.arg(cursor_log_index)::int)
+ORDER BY block_number ASC, COALESCE(log_index, -1) ASC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetDepositsPageDesc :many
+SELECT * FROM deposits
+WHERE contract_address = sqlc.arg(contract_address)
+AND chain_id = sqlc.arg(chain_id)
+AND block_number BETWEEN sqlc.arg(from_block) AND sqlc.arg(to_block)
+AND (confirmed OR NOT sqlc.arg(confirmed_only)::boolean)
+AND (block_number, COALESCE(log_index, -1)) < (sqlc.arg(cursor_block)::int, sqlc.arg(cursor_log_index)::int)
+ORDER BY block_number DESC, COALESCE(log_index, -1) DESC
+LIMIT sqlc.arg(page_size);
 
 -- name: GetEarliestDepositSyncedBlock :one
 SELECT MIN(block_number) FROM deposits 
@@ -28,6 +41,32 @@ WHERE commitment = $1;
 
 -- name: GetLeaves :many
 SELECT commitment FROM deposits
-WHERE contract_address = $1
-AND chain_id = $2
-ORDER BY leaf_index ASC;
\ No newline at end of file
+WHERE contract_address = sqlc.arg(contract_address)
+AND chain_id = sqlc.arg(chain_id)
+AND (confirmed OR NOT sqlc.arg(confirmed_only)::boolean)
+ORDER BY leaf_index ASC;
+
+-- name: GetLeavesFromIndex :many
+SELECT commitment, leaf_index FROM deposits
+WHERE contract_address = sqlc.arg(contract_address)
+AND chain_id = sqlc.arg(chain_id)
+AND leaf_index >= sqlc.arg(from_index)
+AND (confirmed OR NOT sqlc.arg(confirmed_only)::boolean)
+ORDER BY leaf_index ASC;
+
+-- name: DeleteDepositsAfterBlock :execrows
+DELETE FROM deposits
+WHERE chain_id = $1
+AND block_number > $2;
+
+-- name: DeleteDepositsByBlockHash :execrows
+DELETE FROM deposits
+WHERE chain_id = $1
+AND block_hash = $2;
+
+-- name: ConfirmDepositsUpToBlock :execrows
+UPDATE deposits
+SET confirmed = TRUE
+WHERE chain_id = $1
+AND block_number <= $2
+AND NOT confirmed;
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- name: CreateOutboxEvent :exec
+INSERT INTO event_outbox (routing_key, payload)
+VALUES ($1, $2);
+
+-- name: GetPendingOutboxEvents :many
+SELECT * FROM event_outbox
+WHERE published_at IS NULL
+ORDER BY id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE event_outbox
+SET published_at = now()
+WHERE id = $1;
+
+-- name: PrunePublishedOutboxEvents :exec
+DELETE FROM event_outbox
+WHERE published_at IS NOT NULL
+AND published_at < $1;
@@ -0,0 +1,13 @@
+-- name: CreateFlaggedAddress :execrows
+INSERT INTO flagged_addresses (address, source, list_name, list_entry, chain_id, tx_hash)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (LOWER(address), source, list_name, COALESCE(tx_hash, '')) DO NOTHING;
+
+-- name: ListFlaggedAddresses :many
+-- Empty address and source filters match every flag
+SELECT * FROM flagged_addresses
+WHERE (sqlc.arg(address)::text = '' OR LOWER(address) = LOWER(sqlc.arg(address)))
+    AND (sqlc.arg(source)::text = '' OR source = sqlc.arg(source))
+    AND id < sqlc.arg(before_id)
+ORDER BY id DESC
+LIMIT sqlc.arg(max_flags);
@@ -0,0 +1,14 @@
+-- name: CreateKycEvent :exec
+INSERT INTO kyc_events (citizen_id, actor, action, changes, request_id, source_ip)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetKycEvents :many
+SELECT * FROM kyc_events
+WHERE citizen_id = $1 AND id < $2
+ORDER BY id DESC
+LIMIT $3;
+
+-- name: RekeyKycEvents :exec
+UPDATE kyc_events
+SET citizen_id = sqlc.arg(blind_index)
+WHERE citizen_id = sqlc.arg(citizen_id);
@@ -1,6 +1,9 @@
 -- name: CreateKycInfo :one
-INSERT INTO kyc_info (citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, is_active, kyc_verified_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+INSERT INTO kyc_info (
+    citizen_id, nationality, verifier, kyc_verified_at,
+    citizen_id_ciphertext, full_name_ciphertext, phone_number_ciphertext, date_of_birth_ciphertext,
+    data_key_ciphertext, key_id
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 RETURNING *;
 
 -- name: GetKycInfoByCitizenID :one
@@ -9,15 +12,123 @@ SELECT * FROM kyc_info WHERE citizen_id = $1;
 -- name: GetKycInfoByWalletAddress :one
 SELECT k.* FROM kyc_info k
 JOIN wallet_info w ON k.citizen_id = w.citizen_id
-WHERE w.wallet_address = $1;
+WHERE LOWER(w.wallet_address) = LOWER($1);
 
 -- name: UpdateKycInfo :one
 UPDATE kyc_info
-SET full_name = $2, phone_number = $3, date_of_birth = $4, nationality = $5, verifier = $6, is_active = $7, kyc_verified_at = $8
+SET nationality = $2, verifier = $3, kyc_verified_at = $4,
+    citizen_id_ciphertext = $5, full_name_ciphertext = $6, phone_number_ciphertext = $7, date_of_birth_ciphertext = $8,
+    data_key_ciphertext = $9, key_id = $10,
+    full_name = NULL, phone_number = NULL, date_of_birth = NULL
 WHERE citizen_id = $1
 RETURNING *;
 
 -- name: GetKycStatusByWalletAddress :one
-SELECT k.is_active FROM kyc_info k
+SELECT k.status, k.status_reason, k.expires_at FROM kyc_info k
 JOIN wallet_info w ON k.citizen_id = w.citizen_id
-WHERE w.wallet_address = $1;
\ No newline at end of file
+WHERE LOWER(w.wallet_address) = LOWER($1);
+
+-- name: UpdateKycPersonalInfo :one
+-- Changed personal data has to be reviewed again; records past the review are
+-- locked until they expire and need to be verified again
+UPDATE kyc_info
+SET nationality = $2,
+    citizen_id_ciphertext = $3, full_name_ciphertext = $4, phone_number_ciphertext = $5, date_of_birth_ciphertext = $6,
+    data_key_ciphertext = $7, key_id = $8,
+    full_name = NULL, phone_number = NULL, date_of_birth = NULL,
+    status = 'submitted', status_reason = NULL, reviewed_by = NULL, submitted_at = now()
+WHERE citizen_id = $1 AND status IN ('submitted', 'under_review', 'rejected', 'expired')
+RETURNING *;
+
+-- name: ListPendingKycInfo :many
+SELECT * FROM kyc_info
+WHERE status IN ('submitted', 'under_review')
+ORDER BY submitted_at
+LIMIT $1 OFFSET $2;
+
+-- name: TransitionKycStatus :one
+-- Moves a record to a new status if it currently is in one of from_statuses,
+-- stamping the time the new status was entered
+UPDATE kyc_info
+SET status = sqlc.arg(status)::varchar,
+    status_reason = sqlc.arg(status_reason),
+    reviewed_by = CASE WHEN sqlc.arg(status) IN ('approved', 'rejected') THEN sqlc.arg(actor) ELSE reviewed_by END,
+    verifier = CASE WHEN sqlc.arg(status) = 'approved' THEN sqlc.arg(actor) ELSE verifier END,
+    kyc_verified_at = CASE WHEN sqlc.arg(status) = 'approved' THEN now() ELSE kyc_verified_at END,
+    under_review_at = CASE WHEN sqlc.arg(status) = 'under_review' THEN now() ELSE under_review_at END,
+    approved_at = CASE WHEN sqlc.arg(status) = 'approved' THEN now() ELSE approved_at END,
+    rejected_at = CASE WHEN sqlc.arg(status) = 'rejected' THEN now() ELSE rejected_at END,
+    minting_at = CASE WHEN sqlc.arg(status) = 'minting' THEN now() ELSE minting_at END,
+    active_at = CASE WHEN sqlc.arg(status) = 'active' THEN now() ELSE active_at END,
+    suspended_at = CASE WHEN sqlc.arg(status) = 'suspended' THEN now() ELSE suspended_at END,
+    revoking_at = CASE WHEN sqlc.arg(status) = 'revoking' THEN now() ELSE revoking_at END,
+    revoked_at = CASE WHEN sqlc.arg(status) = 'revoked' THEN now() ELSE revoked_at END,
+    expired_at = CASE WHEN sqlc.arg(status) = 'expired' THEN now() ELSE expired_at END,
+    expires_at = CASE WHEN sqlc.arg(status) = 'approved' THEN NULL ELSE expires_at END,
+    expiry_notified_at = CASE WHEN sqlc.arg(status) = 'approved' THEN NULL ELSE expiry_notified_at END
+WHERE citizen_id = sqlc.arg(citizen_id) AND status = ANY(sqlc.arg(from_statuses)::varchar[])
+RETURNING *;
+
+-- name: GetKycInfoForUpdate :one
+SELECT * FROM kyc_info WHERE citizen_id = $1 FOR UPDATE;
+
+-- name: ListKycDataKeysToRotate :many
+SELECT citizen_id, data_key_ciphertext, key_id FROM kyc_info
+WHERE data_key_ciphertext IS NOT NULL AND key_id <> $1
+LIMIT $2;
+
+-- name: UpdateKycDataKey :execrows
+-- Re-wraps a data key, unless the record was rewritten in the meantime
+UPDATE kyc_info
+SET data_key_ciphertext = sqlc.arg(data_key_ciphertext), key_id = sqlc.arg(key_id)
+WHERE citizen_id = sqlc.arg(citizen_id) AND key_id = sqlc.arg(previous_key_id);
+
+-- name: ListPlaintextKycInfo :many
+SELECT * FROM kyc_info
+WHERE data_key_ciphertext IS NULL
+LIMIT $1;
+
+-- name: EncryptKycInfo :execrows
+-- Moves a plaintext record to the encrypted columns and its blind index
+UPDATE kyc_info
+SET citizen_id = sqlc.arg(blind_index),
+    citizen_id_ciphertext = sqlc.arg(citizen_id_ciphertext), full_name_ciphertext = sqlc.arg(full_name_ciphertext),
+    phone_number_ciphertext = sqlc.arg(phone_number_ciphertext), date_of_birth_ciphertext = sqlc.arg(date_of_birth_ciphertext),
+    data_key_ciphertext = sqlc.arg(data_key_ciphertext), key_id = sqlc.arg(key_id),
+    full_name = NULL, phone_number = NULL, date_of_birth = NULL
+WHERE citizen_id = sqlc.arg(citizen_id) AND data_key_ciphertext IS NULL;
+
+-- name: ListKycWithoutExpiry :many
+SELECT * FROM kyc_info
+WHERE expires_at IS NULL AND kyc_verified_at IS NOT NULL
+    AND status IN ('approved', 'minting', 'active', 'suspended')
+LIMIT $1;
+
+-- name: SetKycExpiresAt :exec
+UPDATE kyc_info
+SET expires_at = sqlc.arg(expires_at)
+WHERE citizen_id = sqlc.arg(citizen_id) AND expires_at IS NULL;
+
+-- name: ListKycExpiringBefore :many
+-- Records whose holder was not yet told that they are about to expire
+SELECT * FROM kyc_info
+WHERE status IN ('active', 'suspended') AND expires_at < sqlc.arg(before) AND expiry_notified_at IS NULL
+ORDER BY expires_at
+LIMIT sqlc.arg(max_records);
+
+-- name: MarkKycExpiryNotified :exec
+UPDATE kyc_info
+SET expiry_notified_at = now()
+WHERE citizen_id = $1;
+
+-- name: ListExpiredKyc :many
+SELECT * FROM kyc_info
+WHERE status IN ('active', 'suspended') AND expires_at <= now()
+ORDER BY expires_at
+LIMIT $1;
+
+-- name: ListKycByStatus :many
+SELECT * FROM kyc_info
+WHERE status = $1
+ORDER BY submitted_at
+LIMIT $2;
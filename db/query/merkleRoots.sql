@@ -0,0 +1,25 @@
+-- name: UpsertMerkleRoot :exec
+INSERT INTO merkle_roots (chain_id, contract_address, leaf_index, root)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (chain_id, contract_address, leaf_index) DO UPDATE
+SET root = EXCLUDED.root, created_at = now()
+WHERE merkle_roots.root <> EXCLUDED.root;
+
+-- name: GetMerkleRoots :many
+SELECT * FROM merkle_roots
+WHERE chain_id = $1
+AND contract_address = $2
+AND leaf_index < $3
+ORDER BY leaf_index DESC
+LIMIT $4;
+
+-- name: DeleteMerkleRootsFrom :exec
+DELETE FROM merkle_roots
+WHERE chain_id = $1
+AND contract_address = $2
+AND leaf_index >= $3;
+
+-- name: ListDepositContracts :many
+SELECT DISTINCT chain_id, contract_address FROM deposits
+WHERE chain_id IS NOT NULL
+AND contract_address IS NOT NULL;
@@ -0,0 +1,44 @@
+-- name: AbandonMintJob :execrows
+-- Only pending jobs are abandoned; a sent transaction has to be waited for.
+UPDATE mint_jobs
+SET status = 'failed', last_error = $2, updated_at = now()
+WHERE wallet_address = $1 AND status = 'pending';
+
+-- name: ClaimMintJob :one
+-- Finished jobs start over, e.g. for a wallet whose NFT was burned since;
+-- unfinished jobs are returned as they are so they can be resumed.
+INSERT INTO mint_jobs (wallet_address, citizen_id)
+VALUES ($1, $2)
+ON CONFLICT (wallet_address) DO UPDATE
+SET citizen_id = EXCLUDED.citizen_id,
+    status = CASE WHEN mint_jobs.status IN ('succeeded', 'failed') THEN 'pending' ELSE mint_jobs.status END,
+    tx_hash = CASE WHEN mint_jobs.status IN ('succeeded', 'failed') THEN NULL ELSE mint_jobs.tx_hash END,
+    nonce = CASE WHEN mint_jobs.status IN ('succeeded', 'failed') THEN NULL ELSE mint_jobs.nonce END,
+    outcome = CASE WHEN mint_jobs.status IN ('succeeded', 'failed') THEN NULL ELSE mint_jobs.outcome END,
+    updated_at = now()
+RETURNING *;
+
+-- name: FinishMintJob :exec
+UPDATE mint_jobs
+SET status = $2, outcome = $3, last_error = $4, updated_at = now()
+WHERE wallet_address = $1;
+
+-- name: GetMintJob :one
+SELECT * FROM mint_jobs
+WHERE wallet_address = $1;
+
+-- name: ListUnfinishedMintJobs :many
+SELECT * FROM mint_jobs
+WHERE status IN ('pending', 'sent')
+ORDER BY id;
+
+-- name: MarkMintJobSent :execrows
+-- Abandoned jobs are left alone, so their transaction is never sent.
+UPDATE mint_jobs
+SET status = 'sent', tx_hash = $2, nonce = $3, attempts = attempts + 1, last_error = NULL, updated_at = now()
+WHERE wallet_address = $1 AND status IN ('pending', 'sent');
+
+-- name: SetMintJobStatus :exec
+UPDATE mint_jobs
+SET status = $2, last_error = $3, updated_at = now()
+WHERE wallet_address = $1;
@@ -0,0 +1,9 @@
+-- name: UseSignatureNonce :one
+INSERT INTO signature_nonces (wallet_address, nonce, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (wallet_address, nonce) DO NOTHING
+RETURNING wallet_address;
+
+-- name: DeleteExpiredSignatureNonces :exec
+DELETE FROM signature_nonces
+WHERE expires_at < now();
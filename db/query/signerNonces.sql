@@ -0,0 +1,13 @@
+-- name: GetSignerNonce :one
+SELECT next_nonce FROM signer_nonces
+WHERE chain_id = $1 AND address = $2;
+
+-- name: ReserveSignerNonce :one
+-- The stored nonce is raised to the pending nonce of the chain first, so
+-- nonces used by other senders are skipped
+INSERT INTO signer_nonces (chain_id, address, next_nonce)
+VALUES (sqlc.arg(chain_id), sqlc.arg(address), sqlc.arg(chain_nonce)::bigint + 1)
+ON CONFLICT (chain_id, address) DO UPDATE
+SET next_nonce = GREATEST(signer_nonces.next_nonce, sqlc.arg(chain_nonce)::bigint) + 1,
+    updated_at = now()
+RETURNING (next_nonce - 1)::bigint AS nonce;
@@ -0,0 +1,12 @@
+-- name: CreateSiweNonce :exec
+INSERT INTO siwe_nonces (nonce, expires_at)
+VALUES ($1, $2);
+
+-- name: UseSiweNonce :one
+DELETE FROM siwe_nonces
+WHERE nonce = $1 AND expires_at > now()
+RETURNING nonce;
+
+-- name: DeleteExpiredSiweNonces :exec
+DELETE FROM siwe_nonces
+WHERE expires_at < now();
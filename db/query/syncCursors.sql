@@ -0,0 +1,26 @@
+-- name: AdvanceSyncCursors :exec
+UPDATE sync_cursors
+SET last_synced_block = sqlc.arg(last_synced_block), updated_at = now()
+WHERE chain_id = sqlc.arg(chain_id)
+AND contract_address = sqlc.arg(contract_address)
+AND last_synced_block >= sqlc.arg(covered_from)
+AND last_synced_block < sqlc.arg(last_synced_block);
+
+-- name: GetSyncCursor :one
+SELECT * FROM sync_cursors
+WHERE chain_id = $1
+AND contract_address = $2
+AND event_type = $3;
+
+-- name: UpsertSyncCursor :exec
+INSERT INTO sync_cursors (chain_id, contract_address, event_type, last_synced_block)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (chain_id, contract_address, event_type) DO UPDATE
+SET last_synced_block = GREATEST(sync_cursors.last_synced_block, EXCLUDED.last_synced_block),
+    updated_at = now();
+
+-- name: RewindSyncCursors :exec
+UPDATE sync_cursors
+SET last_synced_block = $2, updated_at = now()
+WHERE chain_id = $1
+AND last_synced_block > $2;
@@ -8,10 +8,30 @@ INSERT INTO wallet_info (
     $1, $2, $3, $4
 ) RETURNING *;
 
--- name: CreateOrUpdateWalletInfo :exec
+-- name: LinkWalletInfo :execrows
+-- Binds a wallet to a citizen unless it is already bound, in any letter case
 INSERT INTO wallet_info (wallet_address, citizen_id, wallet_signature, created_at)
-VALUES ($1, $2, $3, now())
-ON CONFLICT (wallet_address) DO UPDATE
-SET citizen_id = EXCLUDED.citizen_id, 
-    wallet_signature = EXCLUDED.wallet_signature,
-    created_at = now();
\ No newline at end of file
+SELECT sqlc.arg(wallet_address)::varchar, sqlc.arg(citizen_id)::varchar, sqlc.arg(wallet_signature)::text, now()
+WHERE NOT EXISTS (
+    SELECT 1 FROM wallet_info WHERE LOWER(wallet_address) = LOWER(sqlc.arg(wallet_address))
+)
+ON CONFLICT (wallet_address) DO NOTHING;
+
+-- name: UnlinkWalletInfo :execrows
+DELETE FROM wallet_info
+WHERE LOWER(wallet_address) = LOWER(sqlc.arg(wallet_address)) AND citizen_id = sqlc.arg(citizen_id);
+
+-- name: CountWalletsByCitizenID :one
+SELECT COUNT(*) FROM wallet_info
+WHERE citizen_id = $1;
+
+-- name: GetWalletsByCitizenID :many
+SELECT * FROM wallet_info
+WHERE citizen_id = $1
+ORDER BY created_at;
+
+-- name: ListWalletAddresses :many
+SELECT wallet_address FROM wallet_info
+WHERE wallet_address > $1
+ORDER BY wallet_address
+LIMIT $2;
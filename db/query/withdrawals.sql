@@ -1,8 +1,8 @@
 
 -- name: CreateWithdrawal :one
-INSERT INTO withdrawals (contract_address, recipient, nullifier_hash, relayer, fee, tx_hash, timestamp, block_number, chain_id) 
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-ON CONFLICT (nullifier_hash) DO NOTHING
+INSERT INTO withdrawals (contract_address, recipient, nullifier_hash, relayer, fee, tx_hash, timestamp, block_number, chain_id, block_hash, log_index) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (chain_id, contract_address, nullifier_hash) DO NOTHING
 RETURNING *;
 
 -- name: GetAllWithdrawalsOfContract :many
@@ -17,4 +17,46 @@ ORDER BY timestamp ASC;
 SELECT MAX(block_number) FROM withdrawals WHERE contract_address = $1 AND chain_id = $2;
 
 -- name: GetWithdrawalByNullifierHash :one
-SELECT * FROM withdrawals WHERE nullifier_hash = $1;
\ No newline at end of file
+SELECT * FROM withdrawals WHERE nullifier_hash = $1;
+
+-- name: GetWithdrawalsPageAsc :many
+SELECT * FROM withdrawals
+WHERE contract_address = sqlc.arg(contract_address)
+AND chain_id = sqlc.arg(chain_id)
+AND block_number BETWEEN sqlc.arg(from_block) AND sqlc.arg(to_block)
+AND (confirmed OR NOT sqlc.arg(confirmed_only)::boolean)
+AND (block_number, COALESCE(log_index, -1)) > (sqlc.arg(cursor_block)::int, sqlc.arg(cursor_log_index)::int)
+ORDER BY block_number ASC, COALESCE(log_index, -1) ASC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetWithdrawalsPageDesc :many
+SELECT * FROM withdrawals
+WHERE contract_address = sqlc.arg(contract_address)
+AND chain_id = sqlc.arg(chain_id)
+AND block_number BETWEEN sqlc.arg(from_block) AND sqlc.arg(to_block)
+AND (confirmed OR NOT sqlc.arg(confirmed_only)::boolean)
+AND (block_number, COALESCE(log_index, -1)) < (sqlc.arg(cursor_block)::int, sqlc.arg(cursor_log_index)::int)
+ORDER BY block_number DESC, COALESCE(log_index, -1) DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetEarliestWithdrawalSyncedBlock :one
+SELECT MIN(block_number) FROM withdrawals
+WHERE contract_address = $1
+AND chain_id = $2;
+
+-- name: DeleteWithdrawalsAfterBlock :execrows
+DELETE FROM withdrawals
+WHERE chain_id = $1
+AND block_number > $2;
+
+-- name: DeleteWithdrawalsByBlockHash :execrows
+DELETE FROM withdrawals
+WHERE chain_id = $1
+AND block_hash = $2;
+
+-- name: ConfirmWithdrawalsUpToBlock :execrows
+UPDATE withdrawals
+SET confirmed = TRUE
+WHERE chain_id = $1
+AND block_number <= $2
+AND NOT confirmed;
\ No newline at end of file
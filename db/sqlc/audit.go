@@ -0,0 +1,149 @@
+package sqlc
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Audit describes who made a change and where the request came from. It is
+// carried in the context of repository calls and written to kyc_events.
+type Audit struct {
+	Actor     string
+	RequestID string
+	SourceIP  string
+}
+
+type auditKey struct{}
+
+// WithAudit returns a context carrying the audit information.
+func WithAudit(ctx context.Context, audit Audit) context.Context {
+	return context.WithValue(ctx, auditKey{}, audit)
+}
+
+// WithAuditActor returns a context whose audit information has the given actor.
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	audit := AuditFromContext(ctx)
+	audit.Actor = actor
+	return WithAudit(ctx, audit)
+}
+
+// AuditFromContext returns the audit information of a context.
+func AuditFromContext(ctx context.Context) Audit {
+	audit, _ := ctx.Value(auditKey{}).(Audit)
+	return audit
+}
+
+// Actions recorded in kyc_events
+const (
+	KycActionCreate       = "create"
+	KycActionUpdate       = "update"
+	KycActionTransition   = "transition"
+	KycActionLinkWallet   = "link_wallet"
+	KycActionUnlinkWallet = "unlink_wallet"
+)
+
+// kycRedactedFields are personal data whose values are never written to the
+// audit trail, only the fact that they changed.
+var kycRedactedFields = map[string]bool{
+	"full_name":     true,
+	"phone_number":  true,
+	"date_of_birth": true,
+}
+
+// FieldChange is the before and after value of a field in a KYC event.
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// kycAuditFields returns the audited fields of a KYC record by column name.
+func kycAuditFields(kyc *KycInfo) map[string]any {
+	if kyc == nil {
+		return map[string]any{}
+	}
+	return map[string]any{
+		"full_name":       kyc.FullName,
+		"phone_number":    kyc.PhoneNumber,
+		"date_of_birth":   kyc.DateOfBirth,
+		"nationality":     kyc.Nationality,
+		"verifier":        kyc.Verifier,
+		"kyc_verified_at": kyc.KycVerifiedAt,
+		"status":          kyc.Status,
+		"status_reason":   kyc.StatusReason,
+		"reviewed_by":     kyc.ReviewedBy,
+	}
+}
+
+// diffKyc returns the fields that differ between two versions of a record.
+// before is nil for a new record.
+func diffKyc(before *KycInfo, after *KycInfo) map[string]FieldChange {
+	old, updated := kycAuditFields(before), kycAuditFields(after)
+	changes := make(map[string]FieldChange)
+	for field, value := range updated {
+		previous, existed := old[field]
+		if existed && reflect.DeepEqual(previous, value) {
+			continue
+		}
+		if !existed {
+			if isNull(value) {
+				continue
+			}
+			previous = nil
+		}
+		if kycRedactedFields[field] {
+			if previous != nil {
+				previous = "[redacted]"
+			}
+			value = "[redacted]"
+		}
+		changes[field] = FieldChange{Before: previous, After: value}
+	}
+	return changes
+}
+
+// isNull reports whether a field value is SQL NULL.
+func isNull(value any) bool {
+	valuer, ok := value.(driver.Valuer)
+	if !ok {
+		return false
+	}
+	v, err := valuer.Value()
+	return err == nil && v == nil
+}
+
+// recordKycEvent writes the audit event of a change to the record stored
+// under citizenKey with q, which should be bound to the transaction of the
+// change.
+func recordKycEvent(ctx context.Context, q *Queries, citizenKey string, action string, before *KycInfo, after *KycInfo) error {
+	return writeKycEvent(ctx, q, citizenKey, action, diffKyc(before, after))
+}
+
+// recordWalletEvent writes the audit event of a wallet being linked to or
+// unlinked from the record stored under citizenKey.
+func recordWalletEvent(ctx context.Context, q *Queries, citizenKey string, action string, walletAddress string) error {
+	change := FieldChange{After: walletAddress}
+	if action == KycActionUnlinkWallet {
+		change = FieldChange{Before: walletAddress}
+	}
+	return writeKycEvent(ctx, q, citizenKey, action, map[string]FieldChange{"wallet_address": change})
+}
+
+func writeKycEvent(ctx context.Context, q *Queries, citizenKey string, action string, changes map[string]FieldChange) error {
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	audit := AuditFromContext(ctx)
+	return q.CreateKycEvent(ctx, CreateKycEventParams{
+		CitizenID: citizenKey,
+		Actor:     pgtype.Text{String: audit.Actor, Valid: audit.Actor != ""},
+		Action:    action,
+		Changes:   encoded,
+		RequestID: pgtype.Text{String: audit.RequestID, Valid: audit.RequestID != ""},
+		SourceIp:  pgtype.Text{String: audit.SourceIP, Valid: audit.SourceIP != ""},
+	})
+}
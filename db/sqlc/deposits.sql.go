@@ -11,11 +11,32 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const confirmDepositsUpToBlock = `-- name: ConfirmDepositsUpToBlock :execrows
+UPDATE deposits
+SET confirmed = TRUE
+WHERE chain_id = $1
+AND block_number <= $2
+AND NOT confirmed
+`
+
+type ConfirmDepositsUpToBlockParams struct {
+	ChainID     pgtype.Int4
+	BlockNumber pgtype.Int4
+}
+
+func (q *Queries) ConfirmDepositsUpToBlock(ctx context.Context, arg ConfirmDepositsUpToBlockParams) (int64, error) {
+	result, err := q.db.Exec(ctx, confirmDepositsUpToBlock, arg.ChainID, arg.BlockNumber)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const createDeposit = `-- name: CreateDeposit :one
-INSERT INTO deposits (contract_address, commitment, depositor, leaf_index, tx_hash, timestamp, block_number, chain_id) 
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-ON CONFLICT (commitment) DO NOTHING
-RETURNING id, contract_address, commitment, depositor, leaf_index, tx_hash, timestamp, block_number, chain_id
+INSERT INTO deposits (contract_address, commitment, depositor, leaf_index, tx_hash, timestamp, block_number, chain_id, block_hash, log_index) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (chain_id, contract_address, commitment) DO NOTHING
+RETURNING id, contract_address, commitment, depositor, leaf_index, tx_hash, timestamp, block_number, chain_id, block_hash, log_index, confirmed
 `
 
 type CreateDepositParams struct {
@@ -27,6 +48,8 @@ type CreateDepositParams struct {
 	Timestamp       pgtype.Numeric
 	BlockNumber     pgtype.Int4
 	ChainID         pgtype.Int4
+	BlockHash       pgtype.Text
+	LogIndex        pgtype.Int4
 }
 
 func (q *Queries) CreateDeposit(ctx context.Context, arg CreateDepositParams) (Deposit, error) {
@@ -39,6 +62,8 @@ func (q *Queries) CreateDeposit(ctx context.Context, arg CreateDepositParams) (D
 		arg.Timestamp,
 		arg.BlockNumber,
 		arg.ChainID,
+		arg.BlockHash,
+		arg.LogIndex,
 	)
 	var i Deposit
 	err := row.Scan(
@@ -51,12 +76,53 @@ func (q *Queries) CreateDeposit(ctx context.Context, arg CreateDepositParams) (D
 		&i.Timestamp,
 		&i.BlockNumber,
 		&i.ChainID,
+		&i.BlockHash,
+		&i.LogIndex,
+		&i.Confirmed,
 	)
 	return i, err
 }
 
+const deleteDepositsAfterBlock = `-- name: DeleteDepositsAfterBlock :execrows
+DELETE FROM deposits
+WHERE chain_id = $1
+AND block_number > $2
+`
+
+type DeleteDepositsAfterBlockParams struct {
+	ChainID     pgtype.Int4
+	BlockNumber pgtype.Int4
+}
+
+func (q *Queries) DeleteDepositsAfterBlock(ctx context.Context, arg DeleteDepositsAfterBlockParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteDepositsAfterBlock, arg.ChainID, arg.BlockNumber)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteDepositsByBlockHash = `-- name: DeleteDepositsByBlockHash :execrows
+DELETE FROM deposits
+WHERE chain_id = $1
+AND block_hash = $2
+`
+
+type DeleteDepositsByBlockHashParams struct {
+	ChainID   pgtype.Int4
+	BlockHash pgtype.Text
+}
+
+func (q *Queries) DeleteDepositsByBlockHash(ctx context.Context, arg DeleteDepositsByBlockHashParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteDepositsByBlockHash, arg.ChainID, arg.BlockHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getDepositByCommitment = `-- name: GetDepositByCommitment :one
-SELECT id, contract_address, commitment, depositor, leaf_index, tx_hash, timestamp, block_number, chain_id FROM deposits 
+SELECT id, contract_address, commitment, depositor, leaf_index, tx_hash, timestamp, block_number, chain_id, block_hash, log_index, confirmed FROM deposits 
 WHERE commitment = $1
 `
 
@@ -73,31 +139,45 @@ func (q *Queries) GetDepositByCommitment(ctx context.Context, commitment pgtype.
 		&i.Timestamp,
 		&i.BlockNumber,
 		&i.ChainID,
+		&i.BlockHash,
+		&i.LogIndex,
+		&i.Confirmed,
 	)
 	return i, err
 }
 
-const getDepositsFromBlockToBlock = `-- name: GetDepositsFromBlockToBlock :many
-SELECT id, contract_address, commitment, depositor, leaf_index, tx_hash, timestamp, block_number, chain_id FROM deposits 
+const getDepositsPageAsc = `-- name: GetDepositsPageAsc :many
+SELECT id, contract_address, commitment, depositor, leaf_index, tx_hash, timestamp, block_number, chain_id, block_hash, log_index, confirmed FROM deposits
 WHERE contract_address = $1
 AND chain_id = $2
 AND block_number BETWEEN $3 AND $4
-ORDER BY block_number DESC
+AND (confirmed OR NOT $5::boolean)
+AND (block_number, COALESCE(log_index, -1)) > ($6::int, $7::int)
+ORDER BY block_number ASC, COALESCE(log_index, -1) ASC
+LIMIT $8
 `
 
-type GetDepositsFromBlockToBlockParams struct {
+type GetDepositsPageAscParams struct {
 	ContractAddress pgtype.Text
 	ChainID         pgtype.Int4
-	BlockNumber     pgtype.Int4
-	BlockNumber_2   pgtype.Int4
+	FromBlock       pgtype.Int4
+	ToBlock         pgtype.Int4
+	ConfirmedOnly   bool
+	CursorBlock     int32
+	CursorLogIndex  int32
+	PageSize        int32
 }
 
-func (q *Queries) GetDepositsFromBlockToBlock(ctx context.Context, arg GetDepositsFromBlockToBlockParams) ([]Deposit, error) {
-	rows, err := q.db.Query(ctx, getDepositsFromBlockToBlock,
+func (q *Queries) GetDepositsPageAsc(ctx context.Context, arg GetDepositsPageAscParams) ([]Deposit, error) {
+	rows, err := q.db.Query(ctx, getDepositsPageAsc,
 		arg.ContractAddress,
 		arg.ChainID,
-		arg.BlockNumber,
-		arg.BlockNumber_2,
+		arg.FromBlock,
+		arg.ToBlock,
+		arg.ConfirmedOnly,
+		arg.CursorBlock,
+		arg.CursorLogIndex,
+		arg.PageSize,
 	)
 	if err != nil {
 		return nil, err
@@ -116,6 +196,73 @@ func (q *Queries) GetDepositsFromBlockToBlock(ctx context.Context, arg GetDeposi
 			&i.Timestamp,
 			&i.BlockNumber,
 			&i.ChainID,
+			&i.BlockHash,
+			&i.LogIndex,
+			&i.Confirmed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDepositsPageDesc = `-- name: GetDepositsPageDesc :many
+SELECT id, contract_address, commitment, depositor, leaf_index, tx_hash, timestamp, block_number, chain_id, block_hash, log_index, confirmed FROM deposits
+WHERE contract_address = $1
+AND chain_id = $2
+AND block_number BETWEEN $3 AND $4
+AND (confirmed OR NOT $5::boolean)
+AND (block_number, COALESCE(log_index, -1)) < ($6::int, $7::int)
+ORDER BY block_number DESC, COALESCE(log_index, -1) DESC
+LIMIT $8
+`
+
+type GetDepositsPageDescParams struct {
+	ContractAddress pgtype.Text
+	ChainID         pgtype.Int4
+	FromBlock       pgtype.Int4
+	ToBlock         pgtype.Int4
+	ConfirmedOnly   bool
+	CursorBlock     int32
+	CursorLogIndex  int32
+	PageSize        int32
+}
+
+func (q *Queries) GetDepositsPageDesc(ctx context.Context, arg GetDepositsPageDescParams) ([]Deposit, error) {
+	rows, err := q.db.Query(ctx, getDepositsPageDesc,
+		arg.ContractAddress,
+		arg.ChainID,
+		arg.FromBlock,
+		arg.ToBlock,
+		arg.ConfirmedOnly,
+		arg.CursorBlock,
+		arg.CursorLogIndex,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Deposit
+	for rows.Next() {
+		var i Deposit
+		if err := rows.Scan(
+			&i.ID,
+			&i.ContractAddress,
+			&i.Commitment,
+			&i.Depositor,
+			&i.LeafIndex,
+			&i.TxHash,
+			&i.Timestamp,
+			&i.BlockNumber,
+			&i.ChainID,
+			&i.BlockHash,
+			&i.LogIndex,
+			&i.Confirmed,
 		); err != nil {
 			return nil, err
 		}
@@ -167,16 +314,18 @@ const getLeaves = `-- name: GetLeaves :many
 SELECT commitment FROM deposits
 WHERE contract_address = $1
 AND chain_id = $2
+AND (confirmed OR NOT $3::boolean)
 ORDER BY leaf_index ASC
 `
 
 type GetLeavesParams struct {
 	ContractAddress pgtype.Text
 	ChainID         pgtype.Int4
+	ConfirmedOnly   bool
 }
 
 func (q *Queries) GetLeaves(ctx context.Context, arg GetLeavesParams) ([]pgtype.Text, error) {
-	rows, err := q.db.Query(ctx, getLeaves, arg.ContractAddress, arg.ChainID)
+	rows, err := q.db.Query(ctx, getLeaves, arg.ContractAddress, arg.ChainID, arg.ConfirmedOnly)
 	if err != nil {
 		return nil, err
 	}
@@ -194,3 +343,49 @@ func (q *Queries) GetLeaves(ctx context.Context, arg GetLeavesParams) ([]pgtype.
 	}
 	return items, nil
 }
+
+const getLeavesFromIndex = `-- name: GetLeavesFromIndex :many
+SELECT commitment, leaf_index FROM deposits
+WHERE contract_address = $1
+AND chain_id = $2
+AND leaf_index >= $3
+AND (confirmed OR NOT $4::boolean)
+ORDER BY leaf_index ASC
+`
+
+type GetLeavesFromIndexParams struct {
+	ContractAddress pgtype.Text
+	ChainID         pgtype.Int4
+	FromIndex       pgtype.Int4
+	ConfirmedOnly   bool
+}
+
+type GetLeavesFromIndexRow struct {
+	Commitment pgtype.Text
+	LeafIndex  pgtype.Int4
+}
+
+func (q *Queries) GetLeavesFromIndex(ctx context.Context, arg GetLeavesFromIndexParams) ([]GetLeavesFromIndexRow, error) {
+	rows, err := q.db.Query(ctx, getLeavesFromIndex,
+		arg.ContractAddress,
+		arg.ChainID,
+		arg.FromIndex,
+		arg.ConfirmedOnly,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLeavesFromIndexRow
+	for rows.Next() {
+		var i GetLeavesFromIndexRow
+		if err := rows.Scan(&i.Commitment, &i.LeafIndex); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
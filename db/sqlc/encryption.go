@@ -0,0 +1,200 @@
+package sqlc
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// FieldCipher envelope-encrypts the personal data of KYC records. Every write
+// gets a fresh data key, which is stored wrapped by the KMS next to the
+// ciphertexts. Citizen IDs are stored as a keyed blind index so records can
+// still be looked up by them.
+type FieldCipher struct {
+	kms           KMS
+	blindIndexKey []byte
+}
+
+// NewFieldCipher creates a FieldCipher. The blind index key must be at least
+// 32 bytes and must never change, or existing records can no longer be found.
+func NewFieldCipher(keys KMS, blindIndexKey []byte) (*FieldCipher, error) {
+	if len(blindIndexKey) < 32 {
+		return nil, fmt.Errorf("blind index key must be at least 32 bytes")
+	}
+	return &FieldCipher{kms: keys, blindIndexKey: blindIndexKey}, nil
+}
+
+// LoadFieldCipher creates a FieldCipher from a LocalKMS key file and a base64
+// encoded blind index key.
+func LoadFieldCipher(keyFile string, blindIndexKey string) (*FieldCipher, error) {
+	keys, err := LoadLocalKMS(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	indexKey, err := base64.StdEncoding.DecodeString(blindIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("blind index key is not valid base64: %v", err)
+	}
+	return NewFieldCipher(keys, indexKey)
+}
+
+// BlindIndex returns the value stored in place of a citizen ID.
+func (c *FieldCipher) BlindIndex(citizenID string) string {
+	mac := hmac.New(sha256.New, c.blindIndexKey)
+	mac.Write([]byte(citizenID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sealedKyc holds the encrypted columns of a KYC record.
+type sealedKyc struct {
+	CitizenID   []byte
+	FullName    []byte
+	PhoneNumber []byte
+	DateOfBirth []byte
+	DataKey     []byte
+	KeyID       pgtype.Text
+}
+
+// sealKyc encrypts the personal data of a record under a new data key. Each
+// ciphertext is bound to the record's blind index and its column.
+func (c *FieldCipher) sealKyc(ctx context.Context, kyc KycInfo) (sealedKyc, error) {
+	dataKey, err := newDataKey()
+	if err != nil {
+		return sealedKyc{}, err
+	}
+	aead, err := newDataCipher(dataKey)
+	if err != nil {
+		return sealedKyc{}, err
+	}
+	keyID := c.kms.CurrentKeyID()
+	wrapped, err := c.kms.WrapKey(ctx, keyID, dataKey)
+	if err != nil {
+		return sealedKyc{}, fmt.Errorf("failed to wrap data key: %v", err)
+	}
+
+	index := c.BlindIndex(kyc.CitizenID)
+	sealed := sealedKyc{DataKey: wrapped, KeyID: pgtype.Text{String: keyID, Valid: true}}
+	fields := []struct {
+		column string
+		value  pgtype.Text
+		out    *[]byte
+	}{
+		{"citizen_id", pgtype.Text{String: kyc.CitizenID, Valid: true}, &sealed.CitizenID},
+		{"full_name", kyc.FullName, &sealed.FullName},
+		{"phone_number", kyc.PhoneNumber, &sealed.PhoneNumber},
+		{"date_of_birth", formatDate(kyc.DateOfBirth), &sealed.DateOfBirth},
+	}
+	for _, field := range fields {
+		if !field.value.Valid {
+			continue
+		}
+		*field.out, err = sealAEAD(aead, []byte(field.value.String), fieldAAD(index, field.column))
+		if err != nil {
+			return sealedKyc{}, err
+		}
+	}
+	return sealed, nil
+}
+
+// openKyc returns a record with its personal data decrypted and the encrypted
+// columns cleared. Records that were not encrypted yet are returned as is.
+func (c *FieldCipher) openKyc(ctx context.Context, kyc KycInfo) (KycInfo, error) {
+	if kyc.DataKeyCiphertext == nil {
+		return kyc, nil
+	}
+	dataKey, err := c.kms.UnwrapKey(ctx, kyc.KeyID.String, kyc.DataKeyCiphertext)
+	if err != nil {
+		return KycInfo{}, fmt.Errorf("failed to unwrap data key: %v", err)
+	}
+	aead, err := newDataCipher(dataKey)
+	if err != nil {
+		return KycInfo{}, err
+	}
+
+	index := kyc.CitizenID
+	citizenID, err := openField(aead, kyc.CitizenIDCiphertext, index, "citizen_id")
+	if err != nil {
+		return KycInfo{}, err
+	}
+	fullName, err := openField(aead, kyc.FullNameCiphertext, index, "full_name")
+	if err != nil {
+		return KycInfo{}, err
+	}
+	phoneNumber, err := openField(aead, kyc.PhoneNumberCiphertext, index, "phone_number")
+	if err != nil {
+		return KycInfo{}, err
+	}
+	dateOfBirth, err := openField(aead, kyc.DateOfBirthCiphertext, index, "date_of_birth")
+	if err != nil {
+		return KycInfo{}, err
+	}
+
+	kyc.CitizenID = citizenID.String
+	kyc.FullName = fullName
+	kyc.PhoneNumber = phoneNumber
+	kyc.DateOfBirth, err = parseDate(dateOfBirth)
+	if err != nil {
+		return KycInfo{}, err
+	}
+	kyc.CitizenIDCiphertext = nil
+	kyc.FullNameCiphertext = nil
+	kyc.PhoneNumberCiphertext = nil
+	kyc.DateOfBirthCiphertext = nil
+	kyc.DataKeyCiphertext = nil
+	kyc.KeyID = pgtype.Text{}
+	return kyc, nil
+}
+
+// rewrap re-encrypts a wrapped data key with the current KMS key.
+func (c *FieldCipher) rewrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, string, error) {
+	dataKey, err := c.kms.UnwrapKey(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to unwrap data key: %v", err)
+	}
+	current := c.kms.CurrentKeyID()
+	rewrapped, err := c.kms.WrapKey(ctx, current, dataKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to wrap data key: %v", err)
+	}
+	return rewrapped, current, nil
+}
+
+func openField(aead cipher.AEAD, ciphertext []byte, index string, column string) (pgtype.Text, error) {
+	if ciphertext == nil {
+		return pgtype.Text{}, nil
+	}
+	plaintext, err := openAEAD(aead, ciphertext, fieldAAD(index, column))
+	if err != nil {
+		return pgtype.Text{}, fmt.Errorf("failed to decrypt %s: %v", column, err)
+	}
+	return pgtype.Text{String: string(plaintext), Valid: true}, nil
+}
+
+func fieldAAD(index string, column string) []byte {
+	return []byte(index + "/" + column)
+}
+
+func formatDate(date pgtype.Date) pgtype.Text {
+	if !date.Valid {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: date.Time.Format(time.DateOnly), Valid: true}
+}
+
+func parseDate(text pgtype.Text) (pgtype.Date, error) {
+	if !text.Valid {
+		return pgtype.Date{}, nil
+	}
+	date, err := time.Parse(time.DateOnly, text.String)
+	if err != nil {
+		return pgtype.Date{}, err
+	}
+	return pgtype.Date{Time: date, Valid: true}, nil
+}
@@ -0,0 +1,129 @@
+package sqlc
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// KMS wraps and unwraps the data keys used to encrypt personal data at rest
+// with key encryption keys it holds. Key IDs are stored next to the wrapped
+// keys so old keys stay usable after rotation.
+type KMS interface {
+	// CurrentKeyID returns the ID of the key new data keys are wrapped with
+	CurrentKeyID() string
+	WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// LocalKMS is a KMS backed by keys in a local JSON file. It is meant for
+// development; production deployments should use a hosted KMS.
+type LocalKMS struct {
+	currentKeyID string
+	keys         map[string]cipher.AEAD
+}
+
+// localKeyFile is the format of the LocalKMS key file:
+//
+//	{"current_key_id": "2026-10", "keys": {"2026-10": "<base64 32 bytes>"}}
+type localKeyFile struct {
+	CurrentKeyID string            `json:"current_key_id"`
+	Keys         map[string]string `json:"keys"`
+}
+
+// LoadLocalKMS reads a LocalKMS key file.
+func LoadLocalKMS(path string) (*LocalKMS, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %v", err)
+	}
+	var file localKeyFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse key file: %v", err)
+	}
+
+	k := &LocalKMS{currentKeyID: file.CurrentKeyID, keys: make(map[string]cipher.AEAD)}
+	for id, encoded := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("key %q must be 32 base64 encoded bytes", id)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+	}
+	if _, ok := k.keys[k.currentKeyID]; !ok {
+		return nil, fmt.Errorf("current key %q is not in the key file", k.currentKeyID)
+	}
+	return k, nil
+}
+
+// CurrentKeyID returns the ID of the key new data keys are wrapped with.
+func (k *LocalKMS) CurrentKeyID() string {
+	return k.currentKeyID
+}
+
+// WrapKey encrypts a data key with the key keyID.
+func (k *LocalKMS) WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", keyID)
+	}
+	return sealAEAD(aead, dataKey, []byte(keyID))
+}
+
+// UnwrapKey decrypts a data key wrapped with the key keyID.
+func (k *LocalKMS) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", keyID)
+	}
+	return openAEAD(aead, wrapped, []byte(keyID))
+}
+
+// newDataKey returns a random 256-bit data key.
+func newDataKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// newDataCipher returns the AES-256-GCM cipher of a data key.
+func newDataCipher(dataKey []byte) (cipher.AEAD, error) {
+	return newAEAD(dataKey)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealAEAD encrypts plaintext with a random nonce, which is prepended to the result.
+func sealAEAD(aead cipher.AEAD, plaintext []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// openAEAD decrypts a ciphertext produced by sealAEAD.
+func openAEAD(aead cipher.AEAD, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additionalData)
+}
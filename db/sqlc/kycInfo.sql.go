@@ -12,32 +12,39 @@ import (
 )
 
 const createKycInfo = `-- name: CreateKycInfo :one
-INSERT INTO kyc_info (citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, is_active, kyc_verified_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, is_active, kyc_verified_at
+INSERT INTO kyc_info (
+    citizen_id, nationality, verifier, kyc_verified_at,
+    citizen_id_ciphertext, full_name_ciphertext, phone_number_ciphertext, date_of_birth_ciphertext,
+    data_key_ciphertext, key_id
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, kyc_verified_at, status_reason, reviewed_by, submitted_at, status, under_review_at, approved_at, rejected_at, minting_at, active_at, suspended_at, revoked_at, expired_at, citizen_id_ciphertext, full_name_ciphertext, phone_number_ciphertext, date_of_birth_ciphertext, data_key_ciphertext, key_id, revoking_at, expires_at, expiry_notified_at
 `
 
 type CreateKycInfoParams struct {
-	CitizenID     string
-	FullName      pgtype.Text
-	PhoneNumber   pgtype.Text
-	DateOfBirth   pgtype.Date
-	Nationality   pgtype.Text
-	Verifier      pgtype.Text
-	IsActive      pgtype.Bool
-	KycVerifiedAt pgtype.Timestamp
+	CitizenID             string
+	Nationality           pgtype.Text
+	Verifier              pgtype.Text
+	KycVerifiedAt         pgtype.Timestamp
+	CitizenIDCiphertext   []byte
+	FullNameCiphertext    []byte
+	PhoneNumberCiphertext []byte
+	DateOfBirthCiphertext []byte
+	DataKeyCiphertext     []byte
+	KeyID                 pgtype.Text
 }
 
 func (q *Queries) CreateKycInfo(ctx context.Context, arg CreateKycInfoParams) (KycInfo, error) {
 	row := q.db.QueryRow(ctx, createKycInfo,
 		arg.CitizenID,
-		arg.FullName,
-		arg.PhoneNumber,
-		arg.DateOfBirth,
 		arg.Nationality,
 		arg.Verifier,
-		arg.IsActive,
 		arg.KycVerifiedAt,
+		arg.CitizenIDCiphertext,
+		arg.FullNameCiphertext,
+		arg.PhoneNumberCiphertext,
+		arg.DateOfBirthCiphertext,
+		arg.DataKeyCiphertext,
+		arg.KeyID,
 	)
 	var i KycInfo
 	err := row.Scan(
@@ -47,14 +54,73 @@ func (q *Queries) CreateKycInfo(ctx context.Context, arg CreateKycInfoParams) (K
 		&i.DateOfBirth,
 		&i.Nationality,
 		&i.Verifier,
-		&i.IsActive,
 		&i.KycVerifiedAt,
+		&i.StatusReason,
+		&i.ReviewedBy,
+		&i.SubmittedAt,
+		&i.Status,
+		&i.UnderReviewAt,
+		&i.ApprovedAt,
+		&i.RejectedAt,
+		&i.MintingAt,
+		&i.ActiveAt,
+		&i.SuspendedAt,
+		&i.RevokedAt,
+		&i.ExpiredAt,
+		&i.CitizenIDCiphertext,
+		&i.FullNameCiphertext,
+		&i.PhoneNumberCiphertext,
+		&i.DateOfBirthCiphertext,
+		&i.DataKeyCiphertext,
+		&i.KeyID,
+		&i.RevokingAt,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
 	)
 	return i, err
 }
 
+const encryptKycInfo = `-- name: EncryptKycInfo :execrows
+UPDATE kyc_info
+SET citizen_id = $1,
+    citizen_id_ciphertext = $2, full_name_ciphertext = $3,
+    phone_number_ciphertext = $4, date_of_birth_ciphertext = $5,
+    data_key_ciphertext = $6, key_id = $7,
+    full_name = NULL, phone_number = NULL, date_of_birth = NULL
+WHERE citizen_id = $8 AND data_key_ciphertext IS NULL
+`
+
+type EncryptKycInfoParams struct {
+	BlindIndex            string
+	CitizenIDCiphertext   []byte
+	FullNameCiphertext    []byte
+	PhoneNumberCiphertext []byte
+	DateOfBirthCiphertext []byte
+	DataKeyCiphertext     []byte
+	KeyID                 pgtype.Text
+	CitizenID             string
+}
+
+// Moves a plaintext record to the encrypted columns and its blind index
+func (q *Queries) EncryptKycInfo(ctx context.Context, arg EncryptKycInfoParams) (int64, error) {
+	result, err := q.db.Exec(ctx, encryptKycInfo,
+		arg.BlindIndex,
+		arg.CitizenIDCiphertext,
+		arg.FullNameCiphertext,
+		arg.PhoneNumberCiphertext,
+		arg.DateOfBirthCiphertext,
+		arg.DataKeyCiphertext,
+		arg.KeyID,
+		arg.CitizenID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getKycInfoByCitizenID = `-- name: GetKycInfoByCitizenID :one
-SELECT citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, is_active, kyc_verified_at FROM kyc_info WHERE citizen_id = $1
+SELECT citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, kyc_verified_at, status_reason, reviewed_by, submitted_at, status, under_review_at, approved_at, rejected_at, minting_at, active_at, suspended_at, revoked_at, expired_at, citizen_id_ciphertext, full_name_ciphertext, phone_number_ciphertext, date_of_birth_ciphertext, data_key_ciphertext, key_id, revoking_at, expires_at, expiry_notified_at FROM kyc_info WHERE citizen_id = $1
 `
 
 func (q *Queries) GetKycInfoByCitizenID(ctx context.Context, citizenID string) (KycInfo, error) {
@@ -67,16 +133,36 @@ func (q *Queries) GetKycInfoByCitizenID(ctx context.Context, citizenID string) (
 		&i.DateOfBirth,
 		&i.Nationality,
 		&i.Verifier,
-		&i.IsActive,
 		&i.KycVerifiedAt,
+		&i.StatusReason,
+		&i.ReviewedBy,
+		&i.SubmittedAt,
+		&i.Status,
+		&i.UnderReviewAt,
+		&i.ApprovedAt,
+		&i.RejectedAt,
+		&i.MintingAt,
+		&i.ActiveAt,
+		&i.SuspendedAt,
+		&i.RevokedAt,
+		&i.ExpiredAt,
+		&i.CitizenIDCiphertext,
+		&i.FullNameCiphertext,
+		&i.PhoneNumberCiphertext,
+		&i.DateOfBirthCiphertext,
+		&i.DataKeyCiphertext,
+		&i.KeyID,
+		&i.RevokingAt,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
 	)
 	return i, err
 }
 
 const getKycInfoByWalletAddress = `-- name: GetKycInfoByWalletAddress :one
-SELECT k.citizen_id, k.full_name, k.phone_number, k.date_of_birth, k.nationality, k.verifier, k.is_active, k.kyc_verified_at FROM kyc_info k
+SELECT k.citizen_id, k.full_name, k.phone_number, k.date_of_birth, k.nationality, k.verifier, k.kyc_verified_at, k.status_reason, k.reviewed_by, k.submitted_at, k.status, k.under_review_at, k.approved_at, k.rejected_at, k.minting_at, k.active_at, k.suspended_at, k.revoked_at, k.expired_at, k.citizen_id_ciphertext, k.full_name_ciphertext, k.phone_number_ciphertext, k.date_of_birth_ciphertext, k.data_key_ciphertext, k.key_id, k.revoking_at, k.expires_at, k.expiry_notified_at FROM kyc_info k
 JOIN wallet_info w ON k.citizen_id = w.citizen_id
-WHERE w.wallet_address = $1
+WHERE LOWER(w.wallet_address) = LOWER($1)
 `
 
 func (q *Queries) GetKycInfoByWalletAddress(ctx context.Context, walletAddress string) (KycInfo, error) {
@@ -89,53 +175,719 @@ func (q *Queries) GetKycInfoByWalletAddress(ctx context.Context, walletAddress s
 		&i.DateOfBirth,
 		&i.Nationality,
 		&i.Verifier,
-		&i.IsActive,
 		&i.KycVerifiedAt,
+		&i.StatusReason,
+		&i.ReviewedBy,
+		&i.SubmittedAt,
+		&i.Status,
+		&i.UnderReviewAt,
+		&i.ApprovedAt,
+		&i.RejectedAt,
+		&i.MintingAt,
+		&i.ActiveAt,
+		&i.SuspendedAt,
+		&i.RevokedAt,
+		&i.ExpiredAt,
+		&i.CitizenIDCiphertext,
+		&i.FullNameCiphertext,
+		&i.PhoneNumberCiphertext,
+		&i.DateOfBirthCiphertext,
+		&i.DataKeyCiphertext,
+		&i.KeyID,
+		&i.RevokingAt,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+	)
+	return i, err
+}
+
+const getKycInfoForUpdate = `-- name: GetKycInfoForUpdate :one
+SELECT citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, kyc_verified_at, status_reason, reviewed_by, submitted_at, status, under_review_at, approved_at, rejected_at, minting_at, active_at, suspended_at, revoked_at, expired_at, citizen_id_ciphertext, full_name_ciphertext, phone_number_ciphertext, date_of_birth_ciphertext, data_key_ciphertext, key_id, revoking_at, expires_at, expiry_notified_at FROM kyc_info WHERE citizen_id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetKycInfoForUpdate(ctx context.Context, citizenID string) (KycInfo, error) {
+	row := q.db.QueryRow(ctx, getKycInfoForUpdate, citizenID)
+	var i KycInfo
+	err := row.Scan(
+		&i.CitizenID,
+		&i.FullName,
+		&i.PhoneNumber,
+		&i.DateOfBirth,
+		&i.Nationality,
+		&i.Verifier,
+		&i.KycVerifiedAt,
+		&i.StatusReason,
+		&i.ReviewedBy,
+		&i.SubmittedAt,
+		&i.Status,
+		&i.UnderReviewAt,
+		&i.ApprovedAt,
+		&i.RejectedAt,
+		&i.MintingAt,
+		&i.ActiveAt,
+		&i.SuspendedAt,
+		&i.RevokedAt,
+		&i.ExpiredAt,
+		&i.CitizenIDCiphertext,
+		&i.FullNameCiphertext,
+		&i.PhoneNumberCiphertext,
+		&i.DateOfBirthCiphertext,
+		&i.DataKeyCiphertext,
+		&i.KeyID,
+		&i.RevokingAt,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
 	)
 	return i, err
 }
 
 const getKycStatusByWalletAddress = `-- name: GetKycStatusByWalletAddress :one
-SELECT k.is_active FROM kyc_info k
+SELECT k.status, k.status_reason, k.expires_at FROM kyc_info k
 JOIN wallet_info w ON k.citizen_id = w.citizen_id
-WHERE w.wallet_address = $1
+WHERE LOWER(w.wallet_address) = LOWER($1)
 `
 
-func (q *Queries) GetKycStatusByWalletAddress(ctx context.Context, walletAddress string) (pgtype.Bool, error) {
+type GetKycStatusByWalletAddressRow struct {
+	Status       string
+	StatusReason pgtype.Text
+	ExpiresAt    pgtype.Timestamp
+}
+
+func (q *Queries) GetKycStatusByWalletAddress(ctx context.Context, walletAddress string) (GetKycStatusByWalletAddressRow, error) {
 	row := q.db.QueryRow(ctx, getKycStatusByWalletAddress, walletAddress)
-	var is_active pgtype.Bool
-	err := row.Scan(&is_active)
-	return is_active, err
+	var i GetKycStatusByWalletAddressRow
+	err := row.Scan(
+		&i.Status,
+		&i.StatusReason,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const listExpiredKyc = `-- name: ListExpiredKyc :many
+SELECT citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, kyc_verified_at, status_reason, reviewed_by, submitted_at, status, under_review_at, approved_at, rejected_at, minting_at, active_at, suspended_at, revoked_at, expired_at, citizen_id_ciphertext, full_name_ciphertext, phone_number_ciphertext, date_of_birth_ciphertext, data_key_ciphertext, key_id, revoking_at, expires_at, expiry_notified_at FROM kyc_info
+WHERE status IN ('active', 'suspended') AND expires_at <= now()
+ORDER BY expires_at
+LIMIT $1
+`
+
+func (q *Queries) ListExpiredKyc(ctx context.Context, limit int32) ([]KycInfo, error) {
+	rows, err := q.db.Query(ctx, listExpiredKyc, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KycInfo
+	for rows.Next() {
+		var i KycInfo
+		if err := rows.Scan(
+			&i.CitizenID,
+			&i.FullName,
+			&i.PhoneNumber,
+			&i.DateOfBirth,
+			&i.Nationality,
+			&i.Verifier,
+			&i.KycVerifiedAt,
+			&i.StatusReason,
+			&i.ReviewedBy,
+			&i.SubmittedAt,
+			&i.Status,
+			&i.UnderReviewAt,
+			&i.ApprovedAt,
+			&i.RejectedAt,
+			&i.MintingAt,
+			&i.ActiveAt,
+			&i.SuspendedAt,
+			&i.RevokedAt,
+			&i.ExpiredAt,
+			&i.CitizenIDCiphertext,
+			&i.FullNameCiphertext,
+			&i.PhoneNumberCiphertext,
+			&i.DateOfBirthCiphertext,
+			&i.DataKeyCiphertext,
+			&i.KeyID,
+			&i.RevokingAt,
+			&i.ExpiresAt,
+			&i.ExpiryNotifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listKycByStatus = `-- name: ListKycByStatus :many
+SELECT citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, kyc_verified_at, status_reason, reviewed_by, submitted_at, status, under_review_at, approved_at, rejected_at, minting_at, active_at, suspended_at, revoked_at, expired_at, citizen_id_ciphertext, full_name_ciphertext, phone_number_ciphertext, date_of_birth_ciphertext, data_key_ciphertext, key_id, revoking_at, expires_at, expiry_notified_at FROM kyc_info
+WHERE status = $1
+ORDER BY submitted_at
+LIMIT $2
+`
+
+type ListKycByStatusParams struct {
+	Status string
+	Limit  int32
+}
+
+func (q *Queries) ListKycByStatus(ctx context.Context, arg ListKycByStatusParams) ([]KycInfo, error) {
+	rows, err := q.db.Query(ctx, listKycByStatus, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KycInfo
+	for rows.Next() {
+		var i KycInfo
+		if err := rows.Scan(
+			&i.CitizenID,
+			&i.FullName,
+			&i.PhoneNumber,
+			&i.DateOfBirth,
+			&i.Nationality,
+			&i.Verifier,
+			&i.KycVerifiedAt,
+			&i.StatusReason,
+			&i.ReviewedBy,
+			&i.SubmittedAt,
+			&i.Status,
+			&i.UnderReviewAt,
+			&i.ApprovedAt,
+			&i.RejectedAt,
+			&i.MintingAt,
+			&i.ActiveAt,
+			&i.SuspendedAt,
+			&i.RevokedAt,
+			&i.ExpiredAt,
+			&i.CitizenIDCiphertext,
+			&i.FullNameCiphertext,
+			&i.PhoneNumberCiphertext,
+			&i.DateOfBirthCiphertext,
+			&i.DataKeyCiphertext,
+			&i.KeyID,
+			&i.RevokingAt,
+			&i.ExpiresAt,
+			&i.ExpiryNotifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listKycDataKeysToRotate = `-- name: ListKycDataKeysToRotate :many
+SELECT citizen_id, data_key_ciphertext, key_id FROM kyc_info
+WHERE data_key_ciphertext IS NOT NULL AND key_id <> $1
+LIMIT $2
+`
+
+type ListKycDataKeysToRotateRow struct {
+	CitizenID         string
+	DataKeyCiphertext []byte
+	KeyID             pgtype.Text
+}
+
+type ListKycDataKeysToRotateParams struct {
+	KeyID pgtype.Text
+	Limit int32
+}
+
+func (q *Queries) ListKycDataKeysToRotate(ctx context.Context, arg ListKycDataKeysToRotateParams) ([]ListKycDataKeysToRotateRow, error) {
+	rows, err := q.db.Query(ctx, listKycDataKeysToRotate, arg.KeyID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListKycDataKeysToRotateRow
+	for rows.Next() {
+		var i ListKycDataKeysToRotateRow
+		if err := rows.Scan(
+			&i.CitizenID,
+			&i.DataKeyCiphertext,
+			&i.KeyID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listKycExpiringBefore = `-- name: ListKycExpiringBefore :many
+SELECT citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, kyc_verified_at, status_reason, reviewed_by, submitted_at, status, under_review_at, approved_at, rejected_at, minting_at, active_at, suspended_at, revoked_at, expired_at, citizen_id_ciphertext, full_name_ciphertext, phone_number_ciphertext, date_of_birth_ciphertext, data_key_ciphertext, key_id, revoking_at, expires_at, expiry_notified_at FROM kyc_info
+WHERE status IN ('active', 'suspended') AND expires_at < $1 AND expiry_notified_at IS NULL
+ORDER BY expires_at
+LIMIT $2
+`
+
+type ListKycExpiringBeforeParams struct {
+	Before     pgtype.Timestamp
+	MaxRecords int32
+}
+
+// Records whose holder was not yet told that they are about to expire
+func (q *Queries) ListKycExpiringBefore(ctx context.Context, arg ListKycExpiringBeforeParams) ([]KycInfo, error) {
+	rows, err := q.db.Query(ctx, listKycExpiringBefore, arg.Before, arg.MaxRecords)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KycInfo
+	for rows.Next() {
+		var i KycInfo
+		if err := rows.Scan(
+			&i.CitizenID,
+			&i.FullName,
+			&i.PhoneNumber,
+			&i.DateOfBirth,
+			&i.Nationality,
+			&i.Verifier,
+			&i.KycVerifiedAt,
+			&i.StatusReason,
+			&i.ReviewedBy,
+			&i.SubmittedAt,
+			&i.Status,
+			&i.UnderReviewAt,
+			&i.ApprovedAt,
+			&i.RejectedAt,
+			&i.MintingAt,
+			&i.ActiveAt,
+			&i.SuspendedAt,
+			&i.RevokedAt,
+			&i.ExpiredAt,
+			&i.CitizenIDCiphertext,
+			&i.FullNameCiphertext,
+			&i.PhoneNumberCiphertext,
+			&i.DateOfBirthCiphertext,
+			&i.DataKeyCiphertext,
+			&i.KeyID,
+			&i.RevokingAt,
+			&i.ExpiresAt,
+			&i.ExpiryNotifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listKycWithoutExpiry = `-- name: ListKycWithoutExpiry :many
+SELECT citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, kyc_verified_at, status_reason, reviewed_by, submitted_at, status, under_review_at, approved_at, rejected_at, minting_at, active_at, suspended_at, revoked_at, expired_at, citizen_id_ciphertext, full_name_ciphertext, phone_number_ciphertext, date_of_birth_ciphertext, data_key_ciphertext, key_id, revoking_at, expires_at, expiry_notified_at FROM kyc_info
+WHERE expires_at IS NULL AND kyc_verified_at IS NOT NULL
+    AND status IN ('approved', 'minting', 'active', 'suspended')
+LIMIT $1
+`
+
+func (q *Queries) ListKycWithoutExpiry(ctx context.Context, limit int32) ([]KycInfo, error) {
+	rows, err := q.db.Query(ctx, listKycWithoutExpiry, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KycInfo
+	for rows.Next() {
+		var i KycInfo
+		if err := rows.Scan(
+			&i.CitizenID,
+			&i.FullName,
+			&i.PhoneNumber,
+			&i.DateOfBirth,
+			&i.Nationality,
+			&i.Verifier,
+			&i.KycVerifiedAt,
+			&i.StatusReason,
+			&i.ReviewedBy,
+			&i.SubmittedAt,
+			&i.Status,
+			&i.UnderReviewAt,
+			&i.ApprovedAt,
+			&i.RejectedAt,
+			&i.MintingAt,
+			&i.ActiveAt,
+			&i.SuspendedAt,
+			&i.RevokedAt,
+			&i.ExpiredAt,
+			&i.CitizenIDCiphertext,
+			&i.FullNameCiphertext,
+			&i.PhoneNumberCiphertext,
+			&i.DateOfBirthCiphertext,
+			&i.DataKeyCiphertext,
+			&i.KeyID,
+			&i.RevokingAt,
+			&i.ExpiresAt,
+			&i.ExpiryNotifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingKycInfo = `-- name: ListPendingKycInfo :many
+SELECT citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, kyc_verified_at, status_reason, reviewed_by, submitted_at, status, under_review_at, approved_at, rejected_at, minting_at, active_at, suspended_at, revoked_at, expired_at, citizen_id_ciphertext, full_name_ciphertext, phone_number_ciphertext, date_of_birth_ciphertext, data_key_ciphertext, key_id, revoking_at, expires_at, expiry_notified_at FROM kyc_info
+WHERE status IN ('submitted', 'under_review')
+ORDER BY submitted_at
+LIMIT $1 OFFSET $2
+`
+
+type ListPendingKycInfoParams struct {
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) ListPendingKycInfo(ctx context.Context, arg ListPendingKycInfoParams) ([]KycInfo, error) {
+	rows, err := q.db.Query(ctx, listPendingKycInfo, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KycInfo
+	for rows.Next() {
+		var i KycInfo
+		if err := rows.Scan(
+			&i.CitizenID,
+			&i.FullName,
+			&i.PhoneNumber,
+			&i.DateOfBirth,
+			&i.Nationality,
+			&i.Verifier,
+			&i.KycVerifiedAt,
+			&i.StatusReason,
+			&i.ReviewedBy,
+			&i.SubmittedAt,
+			&i.Status,
+			&i.UnderReviewAt,
+			&i.ApprovedAt,
+			&i.RejectedAt,
+			&i.MintingAt,
+			&i.ActiveAt,
+			&i.SuspendedAt,
+			&i.RevokedAt,
+			&i.ExpiredAt,
+			&i.CitizenIDCiphertext,
+			&i.FullNameCiphertext,
+			&i.PhoneNumberCiphertext,
+			&i.DateOfBirthCiphertext,
+			&i.DataKeyCiphertext,
+			&i.KeyID,
+			&i.RevokingAt,
+			&i.ExpiresAt,
+			&i.ExpiryNotifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlaintextKycInfo = `-- name: ListPlaintextKycInfo :many
+SELECT citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, kyc_verified_at, status_reason, reviewed_by, submitted_at, status, under_review_at, approved_at, rejected_at, minting_at, active_at, suspended_at, revoked_at, expired_at, citizen_id_ciphertext, full_name_ciphertext, phone_number_ciphertext, date_of_birth_ciphertext, data_key_ciphertext, key_id, revoking_at, expires_at, expiry_notified_at FROM kyc_info
+WHERE data_key_ciphertext IS NULL
+LIMIT $1
+`
+
+func (q *Queries) ListPlaintextKycInfo(ctx context.Context, limit int32) ([]KycInfo, error) {
+	rows, err := q.db.Query(ctx, listPlaintextKycInfo, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KycInfo
+	for rows.Next() {
+		var i KycInfo
+		if err := rows.Scan(
+			&i.CitizenID,
+			&i.FullName,
+			&i.PhoneNumber,
+			&i.DateOfBirth,
+			&i.Nationality,
+			&i.Verifier,
+			&i.KycVerifiedAt,
+			&i.StatusReason,
+			&i.ReviewedBy,
+			&i.SubmittedAt,
+			&i.Status,
+			&i.UnderReviewAt,
+			&i.ApprovedAt,
+			&i.RejectedAt,
+			&i.MintingAt,
+			&i.ActiveAt,
+			&i.SuspendedAt,
+			&i.RevokedAt,
+			&i.ExpiredAt,
+			&i.CitizenIDCiphertext,
+			&i.FullNameCiphertext,
+			&i.PhoneNumberCiphertext,
+			&i.DateOfBirthCiphertext,
+			&i.DataKeyCiphertext,
+			&i.KeyID,
+			&i.RevokingAt,
+			&i.ExpiresAt,
+			&i.ExpiryNotifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markKycExpiryNotified = `-- name: MarkKycExpiryNotified :exec
+UPDATE kyc_info
+SET expiry_notified_at = now()
+WHERE citizen_id = $1
+`
+
+func (q *Queries) MarkKycExpiryNotified(ctx context.Context, citizenID string) error {
+	_, err := q.db.Exec(ctx, markKycExpiryNotified, citizenID)
+	return err
+}
+
+const setKycExpiresAt = `-- name: SetKycExpiresAt :exec
+UPDATE kyc_info
+SET expires_at = $1
+WHERE citizen_id = $2 AND expires_at IS NULL
+`
+
+type SetKycExpiresAtParams struct {
+	ExpiresAt pgtype.Timestamp
+	CitizenID string
+}
+
+func (q *Queries) SetKycExpiresAt(ctx context.Context, arg SetKycExpiresAtParams) error {
+	_, err := q.db.Exec(ctx, setKycExpiresAt, arg.ExpiresAt, arg.CitizenID)
+	return err
+}
+
+const transitionKycStatus = `-- name: TransitionKycStatus :one
+UPDATE kyc_info
+SET status = $1::varchar,
+    status_reason = $2,
+    reviewed_by = CASE WHEN $1 IN ('approved', 'rejected') THEN $3 ELSE reviewed_by END,
+    verifier = CASE WHEN $1 = 'approved' THEN $3 ELSE verifier END,
+    kyc_verified_at = CASE WHEN $1 = 'approved' THEN now() ELSE kyc_verified_at END,
+    under_review_at = CASE WHEN $1 = 'under_review' THEN now() ELSE under_review_at END,
+    approved_at = CASE WHEN $1 = 'approved' THEN now() ELSE approved_at END,
+    rejected_at = CASE WHEN $1 = 'rejected' THEN now() ELSE rejected_at END,
+    minting_at = CASE WHEN $1 = 'minting' THEN now() ELSE minting_at END,
+    active_at = CASE WHEN $1 = 'active' THEN now() ELSE active_at END,
+    suspended_at = CASE WHEN $1 = 'suspended' THEN now() ELSE suspended_at END,
+    revoking_at = CASE WHEN $1 = 'revoking' THEN now() ELSE revoking_at END,
+    revoked_at = CASE WHEN $1 = 'revoked' THEN now() ELSE revoked_at END,
+    expired_at = CASE WHEN $1 = 'expired' THEN now() ELSE expired_at END,
+    expires_at = CASE WHEN $1 = 'approved' THEN NULL ELSE expires_at END,
+    expiry_notified_at = CASE WHEN $1 = 'approved' THEN NULL ELSE expiry_notified_at END
+WHERE citizen_id = $4 AND status = ANY($5::varchar[])
+RETURNING citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, kyc_verified_at, status_reason, reviewed_by, submitted_at, status, under_review_at, approved_at, rejected_at, minting_at, active_at, suspended_at, revoked_at, expired_at, citizen_id_ciphertext, full_name_ciphertext, phone_number_ciphertext, date_of_birth_ciphertext, data_key_ciphertext, key_id, revoking_at, expires_at, expiry_notified_at
+`
+
+type TransitionKycStatusParams struct {
+	Status       string
+	StatusReason pgtype.Text
+	Actor        pgtype.Text
+	CitizenID    string
+	FromStatuses []string
+}
+
+// Moves a record to a new status if it currently is in one of from_statuses,
+// stamping the time the new status was entered
+func (q *Queries) TransitionKycStatus(ctx context.Context, arg TransitionKycStatusParams) (KycInfo, error) {
+	row := q.db.QueryRow(ctx, transitionKycStatus,
+		arg.Status,
+		arg.StatusReason,
+		arg.Actor,
+		arg.CitizenID,
+		arg.FromStatuses,
+	)
+	var i KycInfo
+	err := row.Scan(
+		&i.CitizenID,
+		&i.FullName,
+		&i.PhoneNumber,
+		&i.DateOfBirth,
+		&i.Nationality,
+		&i.Verifier,
+		&i.KycVerifiedAt,
+		&i.StatusReason,
+		&i.ReviewedBy,
+		&i.SubmittedAt,
+		&i.Status,
+		&i.UnderReviewAt,
+		&i.ApprovedAt,
+		&i.RejectedAt,
+		&i.MintingAt,
+		&i.ActiveAt,
+		&i.SuspendedAt,
+		&i.RevokedAt,
+		&i.ExpiredAt,
+		&i.CitizenIDCiphertext,
+		&i.FullNameCiphertext,
+		&i.PhoneNumberCiphertext,
+		&i.DateOfBirthCiphertext,
+		&i.DataKeyCiphertext,
+		&i.KeyID,
+		&i.RevokingAt,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+	)
+	return i, err
+}
+
+const updateKycDataKey = `-- name: UpdateKycDataKey :execrows
+UPDATE kyc_info
+SET data_key_ciphertext = $1, key_id = $2
+WHERE citizen_id = $3 AND key_id = $4
+`
+
+type UpdateKycDataKeyParams struct {
+	DataKeyCiphertext []byte
+	KeyID             pgtype.Text
+	CitizenID         string
+	PreviousKeyID     pgtype.Text
+}
+
+// Re-wraps a data key, unless the record was rewritten in the meantime
+func (q *Queries) UpdateKycDataKey(ctx context.Context, arg UpdateKycDataKeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateKycDataKey,
+		arg.DataKeyCiphertext,
+		arg.KeyID,
+		arg.CitizenID,
+		arg.PreviousKeyID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const updateKycInfo = `-- name: UpdateKycInfo :one
 UPDATE kyc_info
-SET full_name = $2, phone_number = $3, date_of_birth = $4, nationality = $5, verifier = $6, is_active = $7, kyc_verified_at = $8
+SET nationality = $2, verifier = $3, kyc_verified_at = $4,
+    citizen_id_ciphertext = $5, full_name_ciphertext = $6, phone_number_ciphertext = $7, date_of_birth_ciphertext = $8,
+    data_key_ciphertext = $9, key_id = $10,
+    full_name = NULL, phone_number = NULL, date_of_birth = NULL
 WHERE citizen_id = $1
-RETURNING citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, is_active, kyc_verified_at
+RETURNING citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, kyc_verified_at, status_reason, reviewed_by, submitted_at, status, under_review_at, approved_at, rejected_at, minting_at, active_at, suspended_at, revoked_at, expired_at, citizen_id_ciphertext, full_name_ciphertext, phone_number_ciphertext, date_of_birth_ciphertext, data_key_ciphertext, key_id, revoking_at, expires_at, expiry_notified_at
 `
 
 type UpdateKycInfoParams struct {
-	CitizenID     string
-	FullName      pgtype.Text
-	PhoneNumber   pgtype.Text
-	DateOfBirth   pgtype.Date
-	Nationality   pgtype.Text
-	Verifier      pgtype.Text
-	IsActive      pgtype.Bool
-	KycVerifiedAt pgtype.Timestamp
+	CitizenID             string
+	Nationality           pgtype.Text
+	Verifier              pgtype.Text
+	KycVerifiedAt         pgtype.Timestamp
+	CitizenIDCiphertext   []byte
+	FullNameCiphertext    []byte
+	PhoneNumberCiphertext []byte
+	DateOfBirthCiphertext []byte
+	DataKeyCiphertext     []byte
+	KeyID                 pgtype.Text
 }
 
 func (q *Queries) UpdateKycInfo(ctx context.Context, arg UpdateKycInfoParams) (KycInfo, error) {
 	row := q.db.QueryRow(ctx, updateKycInfo,
 		arg.CitizenID,
-		arg.FullName,
-		arg.PhoneNumber,
-		arg.DateOfBirth,
 		arg.Nationality,
 		arg.Verifier,
-		arg.IsActive,
 		arg.KycVerifiedAt,
+		arg.CitizenIDCiphertext,
+		arg.FullNameCiphertext,
+		arg.PhoneNumberCiphertext,
+		arg.DateOfBirthCiphertext,
+		arg.DataKeyCiphertext,
+		arg.KeyID,
+	)
+	var i KycInfo
+	err := row.Scan(
+		&i.CitizenID,
+		&i.FullName,
+		&i.PhoneNumber,
+		&i.DateOfBirth,
+		&i.Nationality,
+		&i.Verifier,
+		&i.KycVerifiedAt,
+		&i.StatusReason,
+		&i.ReviewedBy,
+		&i.SubmittedAt,
+		&i.Status,
+		&i.UnderReviewAt,
+		&i.ApprovedAt,
+		&i.RejectedAt,
+		&i.MintingAt,
+		&i.ActiveAt,
+		&i.SuspendedAt,
+		&i.RevokedAt,
+		&i.ExpiredAt,
+		&i.CitizenIDCiphertext,
+		&i.FullNameCiphertext,
+		&i.PhoneNumberCiphertext,
+		&i.DateOfBirthCiphertext,
+		&i.DataKeyCiphertext,
+		&i.KeyID,
+		&i.RevokingAt,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
+	)
+	return i, err
+}
+
+const updateKycPersonalInfo = `-- name: UpdateKycPersonalInfo :one
+UPDATE kyc_info
+SET nationality = $2,
+    citizen_id_ciphertext = $3, full_name_ciphertext = $4, phone_number_ciphertext = $5, date_of_birth_ciphertext = $6,
+    data_key_ciphertext = $7, key_id = $8,
+    full_name = NULL, phone_number = NULL, date_of_birth = NULL,
+    status = 'submitted', status_reason = NULL, reviewed_by = NULL, submitted_at = now()
+WHERE citizen_id = $1 AND status IN ('submitted', 'under_review', 'rejected', 'expired')
+RETURNING citizen_id, full_name, phone_number, date_of_birth, nationality, verifier, kyc_verified_at, status_reason, reviewed_by, submitted_at, status, under_review_at, approved_at, rejected_at, minting_at, active_at, suspended_at, revoked_at, expired_at, citizen_id_ciphertext, full_name_ciphertext, phone_number_ciphertext, date_of_birth_ciphertext, data_key_ciphertext, key_id, revoking_at, expires_at, expiry_notified_at
+`
+
+type UpdateKycPersonalInfoParams struct {
+	CitizenID             string
+	Nationality           pgtype.Text
+	CitizenIDCiphertext   []byte
+	FullNameCiphertext    []byte
+	PhoneNumberCiphertext []byte
+	DateOfBirthCiphertext []byte
+	DataKeyCiphertext     []byte
+	KeyID                 pgtype.Text
+}
+
+// Changed personal data has to be reviewed again; records past the review are
+// locked until they expire and need to be verified again
+func (q *Queries) UpdateKycPersonalInfo(ctx context.Context, arg UpdateKycPersonalInfoParams) (KycInfo, error) {
+	row := q.db.QueryRow(ctx, updateKycPersonalInfo,
+		arg.CitizenID,
+		arg.Nationality,
+		arg.CitizenIDCiphertext,
+		arg.FullNameCiphertext,
+		arg.PhoneNumberCiphertext,
+		arg.DateOfBirthCiphertext,
+		arg.DataKeyCiphertext,
+		arg.KeyID,
 	)
 	var i KycInfo
 	err := row.Scan(
@@ -145,8 +897,28 @@ func (q *Queries) UpdateKycInfo(ctx context.Context, arg UpdateKycInfoParams) (K
 		&i.DateOfBirth,
 		&i.Nationality,
 		&i.Verifier,
-		&i.IsActive,
 		&i.KycVerifiedAt,
+		&i.StatusReason,
+		&i.ReviewedBy,
+		&i.SubmittedAt,
+		&i.Status,
+		&i.UnderReviewAt,
+		&i.ApprovedAt,
+		&i.RejectedAt,
+		&i.MintingAt,
+		&i.ActiveAt,
+		&i.SuspendedAt,
+		&i.RevokedAt,
+		&i.ExpiredAt,
+		&i.CitizenIDCiphertext,
+		&i.FullNameCiphertext,
+		&i.PhoneNumberCiphertext,
+		&i.DateOfBirthCiphertext,
+		&i.DataKeyCiphertext,
+		&i.KeyID,
+		&i.RevokingAt,
+		&i.ExpiresAt,
+		&i.ExpiryNotifiedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,73 @@
+package sqlc
+
+import "fmt"
+
+// Statuses of a KYC record.
+const (
+	KycStatusSubmitted   = "submitted"
+	KycStatusUnderReview = "under_review"
+	KycStatusApproved    = "approved"
+	KycStatusRejected    = "rejected"
+	KycStatusMinting     = "minting"
+	KycStatusActive      = "active"
+	KycStatusSuspended   = "suspended"
+	KycStatusRevoking    = "revoking"
+	KycStatusRevoked     = "revoked"
+	KycStatusExpired     = "expired"
+)
+
+// kycTransitions lists, for every status, the statuses it can be entered from.
+//
+//	submitted -> under_review -> approved -> minting -> active -> suspended/revoking/expired
+//
+// A review can also reject a submission, a failed mint moves the record back
+// to approved and a suspension can be lifted. A revocation stays in revoking
+// until the NFT burn is confirmed; revoking a record while it is minting
+// abandons the mints that were not sent yet. Revoked is final.
+//
+// Every record that may still hold an NFT can be revoked: an expired record
+// keeps its NFT unless KYC_REVOKE_ON_EXPIRY is set, and so does one that is
+// verified again after expiring (submitted, under_review or rejected). The
+// burn skips wallets that hold none.
+//
+// Personal data changes move a record back to submitted
+// (UpdateKycPersonalInfo): before it is approved, or once it expired so it can
+// be verified again.
+var kycTransitions = map[string][]string{
+	KycStatusSubmitted:   {KycStatusUnderReview, KycStatusRejected, KycStatusExpired},
+	KycStatusUnderReview: {KycStatusSubmitted},
+	KycStatusApproved:    {KycStatusUnderReview, KycStatusMinting},
+	KycStatusRejected:    {KycStatusUnderReview},
+	KycStatusMinting:     {KycStatusApproved},
+	KycStatusActive:      {KycStatusMinting, KycStatusSuspended},
+	KycStatusSuspended:   {KycStatusActive},
+	KycStatusRevoking:    {KycStatusSubmitted, KycStatusUnderReview, KycStatusApproved, KycStatusRejected, KycStatusMinting, KycStatusActive, KycStatusSuspended, KycStatusExpired},
+	KycStatusRevoked:     {KycStatusRevoking},
+	KycStatusExpired:     {KycStatusActive, KycStatusSuspended},
+}
+
+// KycStatusSources returns the statuses a record can move to status from.
+func KycStatusSources(status string) []string {
+	return kycTransitions[status]
+}
+
+// CanTransitionKyc reports whether a record can move from one status to another.
+func CanTransitionKyc(from string, to string) bool {
+	for _, source := range kycTransitions[to] {
+		if source == from {
+			return true
+		}
+	}
+	return false
+}
+
+// InvalidKycTransitionError is returned when a status change is not allowed
+// from the current status of a record.
+type InvalidKycTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidKycTransitionError) Error() string {
+	return fmt.Sprintf("KYC status cannot change from %s to %s", e.From, e.To)
+}
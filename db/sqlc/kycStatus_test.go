@@ -0,0 +1,25 @@
+package sqlc
+
+import "testing"
+
+func TestCanTransitionKycToRevoking(t *testing.T) {
+	tests := []struct {
+		from string
+		want bool
+	}{
+		{KycStatusExpired, true},
+		{KycStatusSubmitted, true},
+		{KycStatusUnderReview, true},
+		{KycStatusRejected, true},
+		{KycStatusActive, true},
+		{KycStatusSuspended, true},
+		{KycStatusMinting, true},
+		{KycStatusRevoking, false},
+		{KycStatusRevoked, false},
+	}
+	for _, tt := range tests {
+		if got := CanTransitionKyc(tt.from, KycStatusRevoking); got != tt.want {
+			t.Errorf("CanTransitionKyc(%s, %s) = %v, want %v", tt.from, KycStatusRevoking, got, tt.want)
+		}
+	}
+}
@@ -8,6 +8,25 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type BlockHeader struct {
+	ChainID     int32
+	BlockNumber int32
+	BlockHash   string
+	ParentHash  string
+	CreatedAt   pgtype.Timestamp
+}
+
+type BurnJob struct {
+	ID            int64
+	WalletAddress string
+	CitizenID     string
+	Status        string
+	Attempts      int32
+	LastError     pgtype.Text
+	CreatedAt     pgtype.Timestamp
+	UpdatedAt     pgtype.Timestamp
+}
+
 type Deposit struct {
 	ID              int32
 	ContractAddress pgtype.Text
@@ -18,17 +37,120 @@ type Deposit struct {
 	Timestamp       pgtype.Numeric
 	BlockNumber     pgtype.Int4
 	ChainID         pgtype.Int4
+	BlockHash       pgtype.Text
+	LogIndex        pgtype.Int4
+	Confirmed       bool
+}
+
+type EventOutbox struct {
+	ID          int64
+	RoutingKey  string
+	Payload     []byte
+	CreatedAt   pgtype.Timestamp
+	PublishedAt pgtype.Timestamp
+}
+
+type FlaggedAddress struct {
+	ID        int64
+	Address   string
+	Source    string
+	ListName  string
+	ListEntry pgtype.Text
+	ChainID   pgtype.Int4
+	TxHash    pgtype.Text
+	CreatedAt pgtype.Timestamp
+}
+
+type KycEvent struct {
+	ID        int64
+	CitizenID string
+	Actor     pgtype.Text
+	Action    string
+	Changes   []byte
+	RequestID pgtype.Text
+	SourceIp  pgtype.Text
+	CreatedAt pgtype.Timestamp
 }
 
 type KycInfo struct {
+	CitizenID             string
+	FullName              pgtype.Text
+	PhoneNumber           pgtype.Text
+	DateOfBirth           pgtype.Date
+	Nationality           pgtype.Text
+	Verifier              pgtype.Text
+	KycVerifiedAt         pgtype.Timestamp
+	StatusReason          pgtype.Text
+	ReviewedBy            pgtype.Text
+	SubmittedAt           pgtype.Timestamp
+	Status                string
+	UnderReviewAt         pgtype.Timestamp
+	ApprovedAt            pgtype.Timestamp
+	RejectedAt            pgtype.Timestamp
+	MintingAt             pgtype.Timestamp
+	ActiveAt              pgtype.Timestamp
+	SuspendedAt           pgtype.Timestamp
+	RevokedAt             pgtype.Timestamp
+	ExpiredAt             pgtype.Timestamp
+	CitizenIDCiphertext   []byte
+	FullNameCiphertext    []byte
+	PhoneNumberCiphertext []byte
+	DateOfBirthCiphertext []byte
+	DataKeyCiphertext     []byte
+	KeyID                 pgtype.Text
+	RevokingAt            pgtype.Timestamp
+	ExpiresAt             pgtype.Timestamp
+	ExpiryNotifiedAt      pgtype.Timestamp
+}
+
+type MerkleRoot struct {
+	ChainID         int32
+	ContractAddress string
+	LeafIndex       int32
+	Root            string
+	CreatedAt       pgtype.Timestamp
+}
+
+type MintJob struct {
+	ID            int64
+	WalletAddress string
 	CitizenID     string
-	FullName      pgtype.Text
-	PhoneNumber   pgtype.Text
-	DateOfBirth   pgtype.Date
-	Nationality   pgtype.Text
-	Verifier      pgtype.Text
-	IsActive      pgtype.Bool
-	KycVerifiedAt pgtype.Timestamp
+	Status        string
+	TxHash        pgtype.Text
+	Nonce         pgtype.Int8
+	Attempts      int32
+	LastError     pgtype.Text
+	CreatedAt     pgtype.Timestamp
+	UpdatedAt     pgtype.Timestamp
+	Outcome       pgtype.Text
+}
+
+type SignatureNonce struct {
+	WalletAddress string
+	Nonce         string
+	ExpiresAt     pgtype.Timestamp
+	CreatedAt     pgtype.Timestamp
+}
+
+type SignerNonce struct {
+	ChainID   int64
+	Address   string
+	NextNonce int64
+	UpdatedAt pgtype.Timestamp
+}
+
+type SiweNonce struct {
+	Nonce     string
+	ExpiresAt pgtype.Timestamp
+	CreatedAt pgtype.Timestamp
+}
+
+type SyncCursor struct {
+	ChainID         int32
+	ContractAddress string
+	EventType       string
+	LastSyncedBlock int32
+	UpdatedAt       pgtype.Timestamp
 }
 
 type WalletInfo struct {
@@ -49,4 +171,7 @@ type Withdrawal struct {
 	Timestamp       pgtype.Numeric
 	BlockNumber     pgtype.Int4
 	ChainID         pgtype.Int4
+	BlockHash       pgtype.Text
+	LogIndex        pgtype.Int4
+	Confirmed       bool
 }
@@ -11,17 +11,86 @@ import (
 )
 
 type Querier interface {
+	AbandonMintJob(ctx context.Context, arg AbandonMintJobParams) (int64, error)
+	AdvanceSyncCursors(ctx context.Context, arg AdvanceSyncCursorsParams) error
+	ClaimMintJob(ctx context.Context, arg ClaimMintJobParams) (MintJob, error)
+	ConfirmDepositsUpToBlock(ctx context.Context, arg ConfirmDepositsUpToBlockParams) (int64, error)
+	ConfirmWithdrawalsUpToBlock(ctx context.Context, arg ConfirmWithdrawalsUpToBlockParams) (int64, error)
+	CountWalletsByCitizenID(ctx context.Context, citizenID pgtype.Text) (int64, error)
 	CreateDeposit(ctx context.Context, arg CreateDepositParams) (Deposit, error)
+	CreateFlaggedAddress(ctx context.Context, arg CreateFlaggedAddressParams) (int64, error)
+	CreateKycEvent(ctx context.Context, arg CreateKycEventParams) error
 	CreateKycInfo(ctx context.Context, arg CreateKycInfoParams) (KycInfo, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) error
+	CreateSiweNonce(ctx context.Context, arg CreateSiweNonceParams) error
 	CreateWalletInfo(ctx context.Context, arg CreateWalletInfoParams) (WalletInfo, error)
 	CreateWithdrawal(ctx context.Context, arg CreateWithdrawalParams) (Withdrawal, error)
+	DeleteBlockHeadersAfter(ctx context.Context, arg DeleteBlockHeadersAfterParams) error
+	DeleteDepositsAfterBlock(ctx context.Context, arg DeleteDepositsAfterBlockParams) (int64, error)
+	DeleteDepositsByBlockHash(ctx context.Context, arg DeleteDepositsByBlockHashParams) (int64, error)
+	DeleteExpiredSignatureNonces(ctx context.Context) error
+	DeleteExpiredSiweNonces(ctx context.Context) error
+	DeleteMerkleRootsFrom(ctx context.Context, arg DeleteMerkleRootsFromParams) error
+	DeleteWithdrawalsAfterBlock(ctx context.Context, arg DeleteWithdrawalsAfterBlockParams) (int64, error)
+	DeleteWithdrawalsByBlockHash(ctx context.Context, arg DeleteWithdrawalsByBlockHashParams) (int64, error)
+	EncryptKycInfo(ctx context.Context, arg EncryptKycInfoParams) (int64, error)
+	FinishMintJob(ctx context.Context, arg FinishMintJobParams) error
 	GetAllWithdrawalsOfContract(ctx context.Context, contractAddress pgtype.Text) ([]Withdrawal, error)
 	GetAllWithdrawalsOfRecipient(ctx context.Context, recipient pgtype.Text) ([]Withdrawal, error)
-	GetDepositsFromBlockToBlock(ctx context.Context, arg GetDepositsFromBlockToBlockParams) ([]Deposit, error)
+	GetBlockHeader(ctx context.Context, arg GetBlockHeaderParams) (BlockHeader, error)
+	GetDepositsPageAsc(ctx context.Context, arg GetDepositsPageAscParams) ([]Deposit, error)
+	GetDepositsPageDesc(ctx context.Context, arg GetDepositsPageDescParams) ([]Deposit, error)
+	GetEarliestWithdrawalSyncedBlock(ctx context.Context, arg GetEarliestWithdrawalSyncedBlockParams) (interface{}, error)
+	GetKycEvents(ctx context.Context, arg GetKycEventsParams) ([]KycEvent, error)
 	GetKycInfoByCitizenID(ctx context.Context, citizenID string) (KycInfo, error)
 	GetKycInfoByWalletAddress(ctx context.Context, walletAddress string) (KycInfo, error)
+	GetKycInfoForUpdate(ctx context.Context, citizenID string) (KycInfo, error)
+	GetLatestBlockHeader(ctx context.Context, chainID int32) (BlockHeader, error)
 	GetLatestWithdrawalSyncedBlockOfContractOnChain(ctx context.Context, arg GetLatestWithdrawalSyncedBlockOfContractOnChainParams) (interface{}, error)
+	GetLeavesFromIndex(ctx context.Context, arg GetLeavesFromIndexParams) ([]GetLeavesFromIndexRow, error)
+	GetMerkleRoots(ctx context.Context, arg GetMerkleRootsParams) ([]MerkleRoot, error)
+	GetMintJob(ctx context.Context, walletAddress string) (MintJob, error)
+	GetPendingOutboxEvents(ctx context.Context, limit int32) ([]EventOutbox, error)
+	GetSignerNonce(ctx context.Context, arg GetSignerNonceParams) (int64, error)
+	GetSyncCursor(ctx context.Context, arg GetSyncCursorParams) (SyncCursor, error)
+	GetWalletsByCitizenID(ctx context.Context, citizenID pgtype.Text) ([]WalletInfo, error)
+	GetWithdrawalsPageAsc(ctx context.Context, arg GetWithdrawalsPageAscParams) ([]Withdrawal, error)
+	GetWithdrawalsPageDesc(ctx context.Context, arg GetWithdrawalsPageDescParams) ([]Withdrawal, error)
+	LinkWalletInfo(ctx context.Context, arg LinkWalletInfoParams) (int64, error)
+	ListDepositContracts(ctx context.Context) ([]ListDepositContractsRow, error)
+	ListExpiredKyc(ctx context.Context, limit int32) ([]KycInfo, error)
+	ListFlaggedAddresses(ctx context.Context, arg ListFlaggedAddressesParams) ([]FlaggedAddress, error)
+	ListKycByStatus(ctx context.Context, arg ListKycByStatusParams) ([]KycInfo, error)
+	ListKycDataKeysToRotate(ctx context.Context, arg ListKycDataKeysToRotateParams) ([]ListKycDataKeysToRotateRow, error)
+	ListKycExpiringBefore(ctx context.Context, arg ListKycExpiringBeforeParams) ([]KycInfo, error)
+	ListKycWithoutExpiry(ctx context.Context, limit int32) ([]KycInfo, error)
+	ListPendingBurnJobs(ctx context.Context, limit int32) ([]BurnJob, error)
+	ListPendingKycInfo(ctx context.Context, arg ListPendingKycInfoParams) ([]KycInfo, error)
+	ListPlaintextKycInfo(ctx context.Context, limit int32) ([]KycInfo, error)
+	ListUnfinishedMintJobs(ctx context.Context) ([]MintJob, error)
+	ListWalletAddresses(ctx context.Context, arg ListWalletAddressesParams) ([]string, error)
+	MarkKycExpiryNotified(ctx context.Context, citizenID string) error
+	MarkMintJobSent(ctx context.Context, arg MarkMintJobSentParams) (int64, error)
+	MarkOutboxEventPublished(ctx context.Context, id int64) error
+	PruneBlockHeaders(ctx context.Context, arg PruneBlockHeadersParams) error
+	PrunePublishedOutboxEvents(ctx context.Context, publishedAt pgtype.Timestamp) error
+	QueueBurnJob(ctx context.Context, arg QueueBurnJobParams) error
+	RekeyKycEvents(ctx context.Context, arg RekeyKycEventsParams) error
+	ReserveSignerNonce(ctx context.Context, arg ReserveSignerNonceParams) (int64, error)
+	RewindSyncCursors(ctx context.Context, arg RewindSyncCursorsParams) error
+	SetBurnJobStatus(ctx context.Context, arg SetBurnJobStatusParams) error
+	SetKycExpiresAt(ctx context.Context, arg SetKycExpiresAtParams) error
+	SetMintJobStatus(ctx context.Context, arg SetMintJobStatusParams) error
+	TransitionKycStatus(ctx context.Context, arg TransitionKycStatusParams) (KycInfo, error)
+	UnlinkWalletInfo(ctx context.Context, arg UnlinkWalletInfoParams) (int64, error)
+	UpdateKycDataKey(ctx context.Context, arg UpdateKycDataKeyParams) (int64, error)
 	UpdateKycInfo(ctx context.Context, arg UpdateKycInfoParams) (KycInfo, error)
+	UpdateKycPersonalInfo(ctx context.Context, arg UpdateKycPersonalInfoParams) (KycInfo, error)
+	UpsertBlockHeader(ctx context.Context, arg UpsertBlockHeaderParams) error
+	UpsertMerkleRoot(ctx context.Context, arg UpsertMerkleRootParams) error
+	UpsertSyncCursor(ctx context.Context, arg UpsertSyncCursorParams) error
+	UseSignatureNonce(ctx context.Context, arg UseSignatureNonceParams) (string, error)
+	UseSiweNonce(ctx context.Context, nonce string) (string, error)
 	GetDepositByCommitment(ctx context.Context, commitment pgtype.Text) (Deposit, error)
 	GetWithdrawalByNullifierHash(ctx context.Context, nullifierHash pgtype.Text) (Withdrawal, error)
 }
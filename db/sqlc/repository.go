@@ -2,63 +2,157 @@ package sqlc
 
 import (
 	"context"
+	"errors"
+	"math"
 	"strconv"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// TxBeginner is a database handle that can start transactions, such as a
+// *pgxpool.Pool.
+type TxBeginner interface {
+	DBTX
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
 // Repository holds the database queries.
 type Repository struct {
+	db      TxBeginner
 	queries *Queries
+	cipher  *FieldCipher
 }
 
-// NewRepository creates a new Repository instance.
-func NewRepository(queries *Queries) *Repository {
-	return &Repository{queries: queries}
+// NewRepository creates a new Repository instance. KYC personal data is
+// encrypted and decrypted with cipher.
+func NewRepository(db TxBeginner, cipher *FieldCipher) *Repository {
+	return &Repository{db: db, queries: New(db), cipher: cipher}
+}
+
+// inTx runs fn with queries bound to a new transaction, which is committed
+// when fn succeeds.
+func (r *Repository) inTx(ctx context.Context, fn func(q *Queries) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(r.queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
-// SubmitKYC inserts a new KYC record and associated wallet info.
+// Errors returned when wallets are linked to or unlinked from KYC records
+var (
+	ErrWalletLinked    = errors.New("wallet is already linked to a KYC record")
+	ErrWalletNotLinked = errors.New("wallet is not linked to this KYC record")
+	ErrTooManyWallets  = errors.New("KYC record already has the maximum number of wallets")
+	ErrLastWallet      = errors.New("the last wallet of a KYC record cannot be unlinked")
+)
+
+// SubmitKYC inserts a new KYC record and associated wallet info. It returns
+// ErrWalletLinked when the wallet is bound to another record.
 func (r *Repository) SubmitKYC(ctx context.Context, kyc KycInfo, walletAddress string, walletSignature string) error {
-	// Create KYC record
-	_, err := r.queries.CreateKycInfo(ctx, CreateKycInfoParams{
-		CitizenID:     kyc.CitizenID,
-		FullName:      kyc.FullName,
-		PhoneNumber:   kyc.PhoneNumber,
-		DateOfBirth:   kyc.DateOfBirth,
-		Nationality:   kyc.Nationality,
-		Verifier:      kyc.Verifier,
-		IsActive:      kyc.IsActive,
-		KycVerifiedAt: kyc.KycVerifiedAt,
-	})
+	index := r.cipher.BlindIndex(kyc.CitizenID)
+	sealed, err := r.cipher.sealKyc(ctx, kyc)
 	if err != nil {
 		return err
 	}
+	return r.inTx(ctx, func(q *Queries) error {
+		// Create KYC record
+		created, err := q.CreateKycInfo(ctx, CreateKycInfoParams{
+			CitizenID:             index,
+			Nationality:           kyc.Nationality,
+			Verifier:              kyc.Verifier,
+			KycVerifiedAt:         kyc.KycVerifiedAt,
+			CitizenIDCiphertext:   sealed.CitizenID,
+			FullNameCiphertext:    sealed.FullName,
+			PhoneNumberCiphertext: sealed.PhoneNumber,
+			DateOfBirthCiphertext: sealed.DateOfBirth,
+			DataKeyCiphertext:     sealed.DataKey,
+			KeyID:                 sealed.KeyID,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Bind the wallet, which must not belong to another record yet
+		linked, err := q.LinkWalletInfo(ctx, LinkWalletInfoParams{
+			WalletAddress:   walletAddress,
+			CitizenID:       index,
+			WalletSignature: walletSignature,
+		})
+		if err != nil {
+			return err
+		}
+		if linked == 0 {
+			return ErrWalletLinked
+		}
+		after, err := r.cipher.openKyc(ctx, created)
+		if err != nil {
+			return err
+		}
+		if err := recordKycEvent(ctx, q, index, KycActionCreate, nil, &after); err != nil {
+			return err
+		}
+		return recordWalletEvent(ctx, q, index, KycActionLinkWallet, walletAddress)
+	})
+}
+
+// UseSignatureNonce consumes the nonce of a wallet signature. It returns false
+// when the nonce was already used, i.e. the signature is being replayed.
+func (r *Repository) UseSignatureNonce(ctx context.Context, walletAddress string, nonce string, expiresAt time.Time) (bool, error) {
+	// Opportunistically forget nonces whose signatures can no longer be used
+	if err := r.queries.DeleteExpiredSignatureNonces(ctx); err != nil {
+		return false, err
+	}
+	_, err := r.queries.UseSignatureNonce(ctx, UseSignatureNonceParams{
+		WalletAddress: walletAddress,
+		Nonce:         nonce,
+		ExpiresAt:     pgtype.Timestamp{Time: expiresAt, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
 
-	// Create or update wallet info
-	err = r.queries.CreateOrUpdateWalletInfo(ctx, CreateOrUpdateWalletInfoParams{
-		WalletAddress:   walletAddress,
-		CitizenID:       pgtype.Text{String: kyc.CitizenID, Valid: true},
-		WalletSignature: pgtype.Text{String: walletSignature, Valid: true},
+// CreateSiweNonce stores a nonce handed out for a Sign-In-With-Ethereum login.
+func (r *Repository) CreateSiweNonce(ctx context.Context, nonce string, expiresAt time.Time) error {
+	if err := r.queries.DeleteExpiredSiweNonces(ctx); err != nil {
+		return err
+	}
+	return r.queries.CreateSiweNonce(ctx, CreateSiweNonceParams{
+		Nonce:     nonce,
+		ExpiresAt: pgtype.Timestamp{Time: expiresAt, Valid: true},
 	})
-	return err
+}
+
+// UseSiweNonce consumes a login nonce. It returns false when the nonce is
+// unknown, expired or was already used.
+func (r *Repository) UseSiweNonce(ctx context.Context, nonce string) (bool, error) {
+	_, err := r.queries.UseSiweNonce(ctx, nonce)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
 }
 
 // GetKYCByCitizenID retrieves KYC info by citizen ID.
 func (r *Repository) GetKYCByCitizenID(ctx context.Context, citizenID string) (*KycInfo, error) {
-	kyc, err := r.queries.GetKycInfoByCitizenID(ctx, citizenID)
+	kyc, err := r.queries.GetKycInfoByCitizenID(ctx, r.cipher.BlindIndex(citizenID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Records written before encryption are keyed by the plain citizen ID
+		kyc, err = r.queries.GetKycInfoByCitizenID(ctx, citizenID)
+	}
 	if err != nil {
 		return nil, err
 	}
-	return &KycInfo{
-		CitizenID:     kyc.CitizenID,
-		FullName:      pgtype.Text{String: kyc.FullName.String, Valid: true},
-		PhoneNumber:   pgtype.Text{String: kyc.PhoneNumber.String, Valid: true},
-		DateOfBirth:   pgtype.Date{Time: kyc.DateOfBirth.Time, Valid: true},
-		Nationality:   pgtype.Text{String: kyc.Nationality.String, Valid: true},
-		Verifier:      pgtype.Text{String: kyc.Verifier.String, Valid: true},
-		IsActive:      pgtype.Bool{Bool: kyc.IsActive.Bool, Valid: true},
-		KycVerifiedAt: pgtype.Timestamp{Time: kyc.KycVerifiedAt.Time, Valid: true},
-	}, nil
+	return r.openKyc(ctx, kyc)
 }
 
 // GetKYCByWalletAddress retrieves KYC info by wallet address.
@@ -67,34 +161,437 @@ func (r *Repository) GetKYCByWalletAddress(ctx context.Context, walletAddress st
 	if err != nil {
 		return nil, err
 	}
-	return &KycInfo{
-		CitizenID:     kyc.CitizenID,
-		FullName:      pgtype.Text{String: kyc.FullName.String, Valid: true},
-		PhoneNumber:   pgtype.Text{String: kyc.PhoneNumber.String, Valid: true},
-		DateOfBirth:   pgtype.Date{Time: kyc.DateOfBirth.Time, Valid: true},
-		Nationality:   pgtype.Text{String: kyc.Nationality.String, Valid: true},
-		Verifier:      pgtype.Text{String: kyc.Verifier.String, Valid: true},
-		IsActive:      pgtype.Bool{Bool: kyc.IsActive.Bool, Valid: true},
-		KycVerifiedAt: pgtype.Timestamp{Time: kyc.KycVerifiedAt.Time, Valid: true},
-	}, nil
+	return r.openKyc(ctx, kyc)
+}
+
+// openKyc decrypts a stored KYC record.
+func (r *Repository) openKyc(ctx context.Context, kyc KycInfo) (*KycInfo, error) {
+	opened, err := r.cipher.openKyc(ctx, kyc)
+	if err != nil {
+		return nil, err
+	}
+	return &opened, nil
+}
+
+// lockKyc locks a KYC record by citizen ID within q's transaction and returns
+// it decrypted, along with its blind index. A record still stored in
+// plaintext is encrypted first.
+func (r *Repository) lockKyc(ctx context.Context, q *Queries, citizenID string) (KycInfo, string, error) {
+	index := r.cipher.BlindIndex(citizenID)
+	kyc, err := q.GetKycInfoForUpdate(ctx, index)
+	if errors.Is(err, pgx.ErrNoRows) {
+		legacy, legacyErr := q.GetKycInfoForUpdate(ctx, citizenID)
+		if legacyErr != nil {
+			return KycInfo{}, "", legacyErr
+		}
+		if err := r.encryptKyc(ctx, q, legacy); err != nil {
+			return KycInfo{}, "", err
+		}
+		kyc, err = q.GetKycInfoForUpdate(ctx, index)
+	}
+	if err != nil {
+		return KycInfo{}, "", err
+	}
+	opened, err := r.cipher.openKyc(ctx, kyc)
+	return opened, index, err
+}
+
+// encryptKyc moves a plaintext KYC record, its wallets and its audit trail to
+// the blind index and encrypts its personal data.
+func (r *Repository) encryptKyc(ctx context.Context, q *Queries, kyc KycInfo) error {
+	index := r.cipher.BlindIndex(kyc.CitizenID)
+	sealed, err := r.cipher.sealKyc(ctx, kyc)
+	if err != nil {
+		return err
+	}
+	// Wallets follow through the ON UPDATE CASCADE foreign key
+	encrypted, err := q.EncryptKycInfo(ctx, EncryptKycInfoParams{
+		BlindIndex:            index,
+		CitizenIDCiphertext:   sealed.CitizenID,
+		FullNameCiphertext:    sealed.FullName,
+		PhoneNumberCiphertext: sealed.PhoneNumber,
+		DateOfBirthCiphertext: sealed.DateOfBirth,
+		DataKeyCiphertext:     sealed.DataKey,
+		KeyID:                 sealed.KeyID,
+		CitizenID:             kyc.CitizenID,
+	})
+	if err != nil || encrypted == 0 {
+		return err
+	}
+	return q.RekeyKycEvents(ctx, RekeyKycEventsParams{
+		BlindIndex: index,
+		CitizenID:  kyc.CitizenID,
+	})
 }
 
 // UpdateKYC updates an existing KYC record.
 func (r *Repository) UpdateKYC(ctx context.Context, kyc KycInfo) error {
-	_, err := r.queries.UpdateKycInfo(ctx, UpdateKycInfoParams{
-		CitizenID:     kyc.CitizenID,
-		FullName:      kyc.FullName,
-		PhoneNumber:   kyc.PhoneNumber,
-		DateOfBirth:   kyc.DateOfBirth,
-		Nationality:   kyc.Nationality,
-		Verifier:      kyc.Verifier,
-		IsActive:      kyc.IsActive,
-		KycVerifiedAt: kyc.KycVerifiedAt,
+	return r.inTx(ctx, func(q *Queries) error {
+		before, index, err := r.lockKyc(ctx, q, kyc.CitizenID)
+		if err != nil {
+			return err
+		}
+		sealed, err := r.cipher.sealKyc(ctx, kyc)
+		if err != nil {
+			return err
+		}
+		updated, err := q.UpdateKycInfo(ctx, UpdateKycInfoParams{
+			CitizenID:             index,
+			Nationality:           kyc.Nationality,
+			Verifier:              kyc.Verifier,
+			KycVerifiedAt:         kyc.KycVerifiedAt,
+			CitizenIDCiphertext:   sealed.CitizenID,
+			FullNameCiphertext:    sealed.FullName,
+			PhoneNumberCiphertext: sealed.PhoneNumber,
+			DateOfBirthCiphertext: sealed.DateOfBirth,
+			DataKeyCiphertext:     sealed.DataKey,
+			KeyID:                 sealed.KeyID,
+		})
+		if err != nil {
+			return err
+		}
+		after, err := r.cipher.openKyc(ctx, updated)
+		if err != nil {
+			return err
+		}
+		return recordKycEvent(ctx, q, index, KycActionUpdate, &before, &after)
+	})
+}
+
+// UpdateKYCPersonalInfo updates the personal data of a KYC record and sends it
+// back to review. It returns false when the record was already approved.
+func (r *Repository) UpdateKYCPersonalInfo(ctx context.Context, kyc KycInfo) (bool, error) {
+	updated := false
+	err := r.inTx(ctx, func(q *Queries) error {
+		before, index, err := r.lockKyc(ctx, q, kyc.CitizenID)
+		if err != nil {
+			return err
+		}
+		sealed, err := r.cipher.sealKyc(ctx, kyc)
+		if err != nil {
+			return err
+		}
+		row, err := q.UpdateKycPersonalInfo(ctx, UpdateKycPersonalInfoParams{
+			CitizenID:             index,
+			Nationality:           kyc.Nationality,
+			CitizenIDCiphertext:   sealed.CitizenID,
+			FullNameCiphertext:    sealed.FullName,
+			PhoneNumberCiphertext: sealed.PhoneNumber,
+			DateOfBirthCiphertext: sealed.DateOfBirth,
+			DataKeyCiphertext:     sealed.DataKey,
+			KeyID:                 sealed.KeyID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		after, err := r.cipher.openKyc(ctx, row)
+		if err != nil {
+			return err
+		}
+		updated = true
+		return recordKycEvent(ctx, q, index, KycActionUpdate, &before, &after)
+	})
+	return updated, err
+}
+
+// ListPendingKYC returns the KYC submissions waiting for a review, oldest first.
+func (r *Repository) ListPendingKYC(ctx context.Context, limit int32, offset int32) ([]KycInfo, error) {
+	records, err := r.queries.ListPendingKycInfo(ctx, ListPendingKycInfoParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.openKycs(ctx, records)
+}
+
+// TransitionKYC moves a KYC record to a new status, recording the reason and
+// the time the status was entered. Approvals and rejections also record the
+// audit actor of ctx as the reviewer. It returns an *InvalidKycTransitionError
+// when the change is not allowed from the current status and pgx.ErrNoRows
+// when the record does not exist.
+func (r *Repository) TransitionKYC(ctx context.Context, citizenID string, status string, reason string) (*KycInfo, error) {
+	actor := AuditFromContext(ctx).Actor
+	var kyc KycInfo
+	err := r.inTx(ctx, func(q *Queries) error {
+		before, index, err := r.lockKyc(ctx, q, citizenID)
+		if err != nil {
+			return err
+		}
+		if !CanTransitionKyc(before.Status, status) {
+			return &InvalidKycTransitionError{From: before.Status, To: status}
+		}
+
+		row, err := q.TransitionKycStatus(ctx, TransitionKycStatusParams{
+			Status:       status,
+			StatusReason: pgtype.Text{String: reason, Valid: reason != ""},
+			Actor:        pgtype.Text{String: actor, Valid: actor != ""},
+			CitizenID:    index,
+			FromStatuses: KycStatusSources(status),
+		})
+		if err != nil {
+			return err
+		}
+		if kyc, err = r.cipher.openKyc(ctx, row); err != nil {
+			return err
+		}
+		return recordKycEvent(ctx, q, index, KycActionTransition, &before, &kyc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &kyc, nil
+}
+
+// GetKYCEvents returns up to limit audit events of a KYC record, newest first,
+// with IDs below beforeID.
+func (r *Repository) GetKYCEvents(ctx context.Context, citizenID string, beforeID int64, limit int32) ([]KycEvent, error) {
+	return r.queries.GetKycEvents(ctx, GetKycEventsParams{
+		CitizenID: r.cipher.BlindIndex(citizenID),
+		ID:        beforeID,
+		Limit:     limit,
+	})
+}
+
+// GetWalletsByCitizenID returns the wallet addresses bound to a citizen ID.
+func (r *Repository) GetWalletsByCitizenID(ctx context.Context, citizenID string) ([]string, error) {
+	index := pgtype.Text{String: r.cipher.BlindIndex(citizenID), Valid: true}
+	wallets, err := r.queries.GetWalletsByCitizenID(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, len(wallets))
+	for i, wallet := range wallets {
+		addresses[i] = wallet.WalletAddress
+	}
+	return addresses, nil
+}
+
+// ListKYCWallets returns the wallets linked to a citizen ID, oldest first.
+func (r *Repository) ListKYCWallets(ctx context.Context, citizenID string) ([]WalletInfo, error) {
+	return r.queries.GetWalletsByCitizenID(ctx, pgtype.Text{String: r.cipher.BlindIndex(citizenID), Valid: true})
+}
+
+// LinkWallet links another wallet to a KYC record and returns the record. It
+// returns ErrTooManyWallets when the record already has maxWallets wallets
+// and ErrWalletLinked when the wallet is bound to a record already.
+func (r *Repository) LinkWallet(ctx context.Context, citizenID string, walletAddress string, walletSignature string, maxWallets int) (*KycInfo, error) {
+	var kyc KycInfo
+	err := r.inTx(ctx, func(q *Queries) error {
+		var index string
+		var err error
+		// Locking the record serializes the links of a citizen for the limit
+		if kyc, index, err = r.lockKyc(ctx, q, citizenID); err != nil {
+			return err
+		}
+		count, err := q.CountWalletsByCitizenID(ctx, pgtype.Text{String: index, Valid: true})
+		if err != nil {
+			return err
+		}
+		if count >= int64(maxWallets) {
+			return ErrTooManyWallets
+		}
+
+		linked, err := q.LinkWalletInfo(ctx, LinkWalletInfoParams{
+			WalletAddress:   walletAddress,
+			CitizenID:       index,
+			WalletSignature: walletSignature,
+		})
+		if err != nil {
+			return err
+		}
+		if linked == 0 {
+			return ErrWalletLinked
+		}
+		return recordWalletEvent(ctx, q, index, KycActionLinkWallet, walletAddress)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &kyc, nil
+}
+
+// UnlinkWallet removes a wallet from a KYC record and returns the record. The
+// burn job of the wallet's NFT is queued in the same transaction, so a wallet
+// is never unlinked without one; walletAddress is stored in the job as given.
+// It returns ErrWalletNotLinked when the wallet is not linked to the record
+// and ErrLastWallet when it is the only wallet left.
+func (r *Repository) UnlinkWallet(ctx context.Context, citizenID string, walletAddress string) (*KycInfo, error) {
+	var kyc KycInfo
+	err := r.inTx(ctx, func(q *Queries) error {
+		var index string
+		var err error
+		if kyc, index, err = r.lockKyc(ctx, q, citizenID); err != nil {
+			return err
+		}
+		unlinked, err := q.UnlinkWalletInfo(ctx, UnlinkWalletInfoParams{
+			WalletAddress: walletAddress,
+			CitizenID:     pgtype.Text{String: index, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if unlinked == 0 {
+			return ErrWalletNotLinked
+		}
+		remaining, err := q.CountWalletsByCitizenID(ctx, pgtype.Text{String: index, Valid: true})
+		if err != nil {
+			return err
+		}
+		if remaining == 0 {
+			return ErrLastWallet
+		}
+		err = q.QueueBurnJob(ctx, QueueBurnJobParams{
+			WalletAddress: walletAddress,
+			CitizenID:     index,
+		})
+		if err != nil {
+			return err
+		}
+		return recordWalletEvent(ctx, q, index, KycActionUnlinkWallet, walletAddress)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &kyc, nil
+}
+
+// ListKYCWithoutExpiry returns up to limit approved records whose expiry was
+// not determined yet.
+func (r *Repository) ListKYCWithoutExpiry(ctx context.Context, limit int32) ([]KycInfo, error) {
+	records, err := r.queries.ListKycWithoutExpiry(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	return r.openKycs(ctx, records)
+}
+
+// SetKYCExpiry sets when a KYC record expires, unless it is already set.
+func (r *Repository) SetKYCExpiry(ctx context.Context, citizenID string, expiresAt time.Time) error {
+	return r.queries.SetKycExpiresAt(ctx, SetKycExpiresAtParams{
+		ExpiresAt: pgtype.Timestamp{Time: expiresAt, Valid: true},
+		CitizenID: r.cipher.BlindIndex(citizenID),
+	})
+}
+
+// ListKYCExpiringBefore returns up to limit active or suspended records that
+// expire before the given time and whose holder was not notified yet.
+func (r *Repository) ListKYCExpiringBefore(ctx context.Context, before time.Time, limit int32) ([]KycInfo, error) {
+	records, err := r.queries.ListKycExpiringBefore(ctx, ListKycExpiringBeforeParams{
+		Before:     pgtype.Timestamp{Time: before, Valid: true},
+		MaxRecords: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.openKycs(ctx, records)
+}
+
+// MarkKYCExpiryNotified records that the holder of a KYC record was told that
+// it is about to expire.
+func (r *Repository) MarkKYCExpiryNotified(ctx context.Context, citizenID string) error {
+	return r.queries.MarkKycExpiryNotified(ctx, r.cipher.BlindIndex(citizenID))
+}
+
+// ListExpiredKYC returns up to limit active or suspended records that are past
+// their expiry.
+func (r *Repository) ListExpiredKYC(ctx context.Context, limit int32) ([]KycInfo, error) {
+	records, err := r.queries.ListExpiredKyc(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	return r.openKycs(ctx, records)
+}
+
+// ListKYCByStatus returns up to limit records in status, oldest first.
+func (r *Repository) ListKYCByStatus(ctx context.Context, status string, limit int32) ([]KycInfo, error) {
+	records, err := r.queries.ListKycByStatus(ctx, ListKycByStatusParams{Status: status, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	return r.openKycs(ctx, records)
+}
+
+// openKycs decrypts stored KYC records in place.
+func (r *Repository) openKycs(ctx context.Context, records []KycInfo) ([]KycInfo, error) {
+	var err error
+	for i, kyc := range records {
+		if records[i], err = r.cipher.openKyc(ctx, kyc); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// EncryptLegacyKYC encrypts up to batch KYC records still stored in plaintext
+// and returns how many were encrypted.
+func (r *Repository) EncryptLegacyKYC(ctx context.Context, batch int32) (int, error) {
+	legacy, err := r.queries.ListPlaintextKycInfo(ctx, batch)
+	if err != nil {
+		return 0, err
+	}
+	for i, kyc := range legacy {
+		if err := r.inTx(ctx, func(q *Queries) error { return r.encryptKyc(ctx, q, kyc) }); err != nil {
+			return i, err
+		}
+	}
+	return len(legacy), nil
+}
+
+// RotateKYCKeys re-wraps up to batch data keys that are not wrapped with the
+// current KMS key and returns how many were re-wrapped.
+func (r *Repository) RotateKYCKeys(ctx context.Context, batch int32) (int, error) {
+	rows, err := r.queries.ListKycDataKeysToRotate(ctx, ListKycDataKeysToRotateParams{
+		KeyID: pgtype.Text{String: r.cipher.kms.CurrentKeyID(), Valid: true},
+		Limit: batch,
 	})
-	return err
+	if err != nil {
+		return 0, err
+	}
+	rotated := 0
+	for _, row := range rows {
+		wrapped, keyID, err := r.cipher.rewrap(ctx, row.KeyID.String, row.DataKeyCiphertext)
+		if err != nil {
+			return rotated, err
+		}
+		// A record rewritten meanwhile already has a new data key
+		updated, err := r.queries.UpdateKycDataKey(ctx, UpdateKycDataKeyParams{
+			DataKeyCiphertext: wrapped,
+			KeyID:             pgtype.Text{String: keyID, Valid: true},
+			CitizenID:         row.CitizenID,
+			PreviousKeyID:     row.KeyID,
+		})
+		if err != nil {
+			return rotated, err
+		}
+		rotated += int(updated)
+	}
+	return rotated, nil
+}
+
+// EventCursor is the position of an event in (block_number, log_index) order.
+// Events indexed without a log index are positioned at log index -1.
+type EventCursor struct {
+	BlockNumber int32
+	LogIndex    int32
+}
+
+// EventPage selects one page of an event query: up to Limit events strictly
+// after the After cursor (or from the start of the range when it is nil).
+type EventPage struct {
+	After      *EventCursor
+	Descending bool
+	Limit      int32
 }
 
-func (r *Repository) GetDepositEventsFromBlockToBlock(ctx context.Context, netId string, contractAddress string, fromBlock string, toBlock string) ([]Deposit, error) {
+// GetDepositEventsFromBlockToBlock returns one page of the deposits of a
+// contract within a block range. With confirmedOnly set, deposits still
+// awaiting confirmations are left out.
+func (r *Repository) GetDepositEventsFromBlockToBlock(ctx context.Context, netId string, contractAddress string, fromBlock string, toBlock string, confirmedOnly bool, page EventPage) ([]Deposit, error) {
 	fromBlockInt, _ := strconv.ParseInt(fromBlock, 10, 32)
 	toBlockInt, _ := strconv.ParseInt(toBlock, 10, 32)
 	netIdInt, _ := strconv.ParseInt(netId, 10, 32)
@@ -105,7 +602,9 @@ func (r *Repository) GetDepositEventsFromBlockToBlock(ctx context.Context, netId
 			ChainID:         pgtype.Int4{Int32: int32(netIdInt), Valid: true},
 		})
 		if err == nil {
-			fromBlockInt = int64(res.(int32))
+			if block, ok := res.(int32); ok {
+				fromBlockInt = int64(block)
+			}
 		}
 	}
 
@@ -115,17 +614,118 @@ func (r *Repository) GetDepositEventsFromBlockToBlock(ctx context.Context, netId
 			ChainID:         pgtype.Int4{Int32: int32(netIdInt), Valid: true},
 		})
 		if err == nil {
-			toBlockInt = int64(res.(int32))
+			if block, ok := res.(int32); ok {
+				toBlockInt = int64(block)
+			}
 		}
 	}
 
-	events, err := r.queries.GetDepositsFromBlockToBlock(ctx, GetDepositsFromBlockToBlockParams{
-		BlockNumber:     pgtype.Int4{Int32: int32(fromBlockInt), Valid: true},
-		BlockNumber_2:   pgtype.Int4{Int32: int32(toBlockInt), Valid: true},
-		ChainID:         pgtype.Int4{Int32: int32(netIdInt), Valid: true},
-		ContractAddress: pgtype.Text{String: contractAddress, Valid: true},
+	contract := pgtype.Text{String: contractAddress, Valid: true}
+	chainID := pgtype.Int4{Int32: int32(netIdInt), Valid: true}
+	from := pgtype.Int4{Int32: int32(fromBlockInt), Valid: true}
+	to := pgtype.Int4{Int32: int32(toBlockInt), Valid: true}
+
+	if page.Descending {
+		cursor := EventCursor{BlockNumber: int32(toBlockInt), LogIndex: math.MaxInt32}
+		if page.After != nil {
+			cursor = *page.After
+		}
+		return r.queries.GetDepositsPageDesc(ctx, GetDepositsPageDescParams{
+			ContractAddress: contract,
+			ChainID:         chainID,
+			FromBlock:       from,
+			ToBlock:         to,
+			ConfirmedOnly:   confirmedOnly,
+			CursorBlock:     cursor.BlockNumber,
+			CursorLogIndex:  cursor.LogIndex,
+			PageSize:        page.Limit,
+		})
+	}
+
+	cursor := EventCursor{BlockNumber: int32(fromBlockInt), LogIndex: -2}
+	if page.After != nil {
+		cursor = *page.After
+	}
+	return r.queries.GetDepositsPageAsc(ctx, GetDepositsPageAscParams{
+		ContractAddress: contract,
+		ChainID:         chainID,
+		FromBlock:       from,
+		ToBlock:         to,
+		ConfirmedOnly:   confirmedOnly,
+		CursorBlock:     cursor.BlockNumber,
+		CursorLogIndex:  cursor.LogIndex,
+		PageSize:        page.Limit,
+	})
+}
+
+// GetWithdrawalEventsFromBlockToBlock returns one page of the withdrawals of a
+// contract within a block range. With confirmedOnly set, withdrawals still
+// awaiting confirmations are left out.
+func (r *Repository) GetWithdrawalEventsFromBlockToBlock(ctx context.Context, netId string, contractAddress string, fromBlock string, toBlock string, confirmedOnly bool, page EventPage) ([]Withdrawal, error) {
+	fromBlockInt, _ := strconv.ParseInt(fromBlock, 10, 32)
+	toBlockInt, _ := strconv.ParseInt(toBlock, 10, 32)
+	netIdInt, _ := strconv.ParseInt(netId, 10, 32)
+
+	if fromBlockInt == 0 {
+		res, err := r.queries.GetEarliestWithdrawalSyncedBlock(ctx, GetEarliestWithdrawalSyncedBlockParams{
+			ContractAddress: pgtype.Text{String: contractAddress, Valid: true},
+			ChainID:         pgtype.Int4{Int32: int32(netIdInt), Valid: true},
+		})
+		if err == nil {
+			if block, ok := res.(int32); ok {
+				fromBlockInt = int64(block)
+			}
+		}
+	}
+
+	if toBlockInt == 0 {
+		res, err := r.queries.GetLatestWithdrawalSyncedBlockOfContractOnChain(ctx, GetLatestWithdrawalSyncedBlockOfContractOnChainParams{
+			ContractAddress: pgtype.Text{String: contractAddress, Valid: true},
+			ChainID:         pgtype.Int4{Int32: int32(netIdInt), Valid: true},
+		})
+		if err == nil {
+			if block, ok := res.(int32); ok {
+				toBlockInt = int64(block)
+			}
+		}
+	}
+
+	contract := pgtype.Text{String: contractAddress, Valid: true}
+	chainID := pgtype.Int4{Int32: int32(netIdInt), Valid: true}
+	from := pgtype.Int4{Int32: int32(fromBlockInt), Valid: true}
+	to := pgtype.Int4{Int32: int32(toBlockInt), Valid: true}
+
+	if page.Descending {
+		cursor := EventCursor{BlockNumber: int32(toBlockInt), LogIndex: math.MaxInt32}
+		if page.After != nil {
+			cursor = *page.After
+		}
+		return r.queries.GetWithdrawalsPageDesc(ctx, GetWithdrawalsPageDescParams{
+			ContractAddress: contract,
+			ChainID:         chainID,
+			FromBlock:       from,
+			ToBlock:         to,
+			ConfirmedOnly:   confirmedOnly,
+			CursorBlock:     cursor.BlockNumber,
+			CursorLogIndex:  cursor.LogIndex,
+			PageSize:        page.Limit,
+		})
+	}
+
+	cursor := EventCursor{BlockNumber: int32(fromBlockInt), LogIndex: -2}
+	if page.After != nil {
+		cursor = *page.After
+	}
+	return r.queries.GetWithdrawalsPageAsc(ctx, GetWithdrawalsPageAscParams{
+		ContractAddress: contract,
+		ChainID:         chainID,
+		FromBlock:       from,
+		ToBlock:         to,
+		ConfirmedOnly:   confirmedOnly,
+		CursorBlock:     cursor.BlockNumber,
+		CursorLogIndex:  cursor.LogIndex,
+		PageSize:        page.Limit,
 	})
-	return events, err
 }
 
 func (r *Repository) GetWithdrawalByNullifierHash(ctx context.Context, nullifierHash string) (*Withdrawal, error) {
@@ -140,6 +740,7 @@ func (r *Repository) GetWithdrawalByNullifierHash(ctx context.Context, nullifier
 		TxHash:          withdrawal.TxHash,
 		Timestamp:       withdrawal.Timestamp,
 		BlockNumber:     withdrawal.BlockNumber,
+		Confirmed:       withdrawal.Confirmed,
 	}, err
 }
 
@@ -157,15 +758,21 @@ func (r *Repository) GetDepositByCommitment(ctx context.Context, commitment stri
 		Timestamp:       deposit.Timestamp,
 		BlockNumber:     deposit.BlockNumber,
 		ChainID:         deposit.ChainID,
+		BlockHash:       deposit.BlockHash,
+		LogIndex:        deposit.LogIndex,
+		Confirmed:       deposit.Confirmed,
 	}, err
 }
 
-func (r *Repository) GetLeaves(ctx context.Context, netId string, contractAddress string) ([]string, error) {
+// GetLeaves returns the commitments of a contract ordered by leaf index. With
+// confirmedOnly set, only settled leaves are returned.
+func (r *Repository) GetLeaves(ctx context.Context, netId string, contractAddress string, confirmedOnly bool) ([]string, error) {
 	netIdInt, _ := strconv.ParseInt(netId, 10, 32)
 
 	leaves, err := r.queries.GetLeaves(ctx, GetLeavesParams{
 		ChainID:         pgtype.Int4{Int32: int32(netIdInt), Valid: true},
 		ContractAddress: pgtype.Text{String: contractAddress, Valid: true},
+		ConfirmedOnly:   confirmedOnly,
 	})
 
 	result := make([]string, len(leaves))
@@ -175,7 +782,248 @@ func (r *Repository) GetLeaves(ctx context.Context, netId string, contractAddres
 	return result, err
 }
 
-// GetKYCStatusByWalletAddress returns only the is_active status for a wallet address.
-func (r *Repository) GetKYCStatusByWalletAddress(ctx context.Context, walletAddress string) (pgtype.Bool, error) {
-	return r.queries.GetKycStatusByWalletAddress(ctx, walletAddress)
+// GetLeavesFromIndex returns the commitments of a contract from leaf index
+// fromIndex onwards, ordered by leaf index. With confirmedOnly, deposits that
+// are not confirmed yet are left out.
+func (r *Repository) GetLeavesFromIndex(ctx context.Context, netId string, contractAddress string, fromIndex int32, confirmedOnly bool) ([]GetLeavesFromIndexRow, error) {
+	netIdInt, _ := strconv.ParseInt(netId, 10, 32)
+
+	return r.queries.GetLeavesFromIndex(ctx, GetLeavesFromIndexParams{
+		ContractAddress: pgtype.Text{String: contractAddress, Valid: true},
+		ChainID:         pgtype.Int4{Int32: int32(netIdInt), Valid: true},
+		FromIndex:       pgtype.Int4{Int32: fromIndex, Valid: true},
+		ConfirmedOnly:   confirmedOnly,
+	})
+}
+
+// SaveMerkleRoot records the root of a contract's deposit tree after the
+// deposit at leafIndex.
+func (r *Repository) SaveMerkleRoot(ctx context.Context, netId string, contractAddress string, leafIndex int32, root string) error {
+	netIdInt, _ := strconv.ParseInt(netId, 10, 32)
+
+	return r.queries.UpsertMerkleRoot(ctx, UpsertMerkleRootParams{
+		ChainID:         int32(netIdInt),
+		ContractAddress: contractAddress,
+		LeafIndex:       leafIndex,
+		Root:            root,
+	})
+}
+
+// DeleteMerkleRootsFrom drops the recorded roots from leafIndex onwards, after
+// the deposits behind them were rolled back.
+func (r *Repository) DeleteMerkleRootsFrom(ctx context.Context, netId string, contractAddress string, leafIndex int32) error {
+	netIdInt, _ := strconv.ParseInt(netId, 10, 32)
+
+	return r.queries.DeleteMerkleRootsFrom(ctx, DeleteMerkleRootsFromParams{
+		ChainID:         int32(netIdInt),
+		ContractAddress: contractAddress,
+		LeafIndex:       leafIndex,
+	})
+}
+
+// GetMerkleRoots returns the latest limit roots of a contract before leaf
+// index beforeIndex, newest first.
+func (r *Repository) GetMerkleRoots(ctx context.Context, netId string, contractAddress string, beforeIndex int32, limit int32) ([]MerkleRoot, error) {
+	netIdInt, _ := strconv.ParseInt(netId, 10, 32)
+
+	return r.queries.GetMerkleRoots(ctx, GetMerkleRootsParams{
+		ChainID:         int32(netIdInt),
+		ContractAddress: contractAddress,
+		LeafIndex:       beforeIndex,
+		Limit:           limit,
+	})
+}
+
+// ListDepositContracts returns every (chain, contract) pair with deposits.
+func (r *Repository) ListDepositContracts(ctx context.Context) ([]ListDepositContractsRow, error) {
+	return r.queries.ListDepositContracts(ctx)
+}
+
+// GetKYCStatusByWalletAddress returns the status of the KYC record of a wallet
+// address, with the reason of the status and when the record expires.
+func (r *Repository) GetKYCStatusByWalletAddress(ctx context.Context, walletAddress string) (*GetKycStatusByWalletAddressRow, error) {
+	status, err := r.queries.GetKycStatusByWalletAddress(ctx, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// ListWalletAddresses returns up to limit wallet addresses linked to a KYC
+// record, ordered by address and starting after the given address.
+func (r *Repository) ListWalletAddresses(ctx context.Context, after string, limit int32) ([]string, error) {
+	return r.queries.ListWalletAddresses(ctx, ListWalletAddressesParams{
+		WalletAddress: after,
+		Limit:         limit,
+	})
+}
+
+// FlagAddress stores a denylist match. It reports false when the same match
+// was stored before.
+func (r *Repository) FlagAddress(ctx context.Context, arg CreateFlaggedAddressParams) (bool, error) {
+	created, err := r.queries.CreateFlaggedAddress(ctx, arg)
+	if err != nil {
+		return false, err
+	}
+	return created > 0, nil
+}
+
+// ListFlaggedAddresses returns up to limit denylist matches with an ID below
+// beforeID, newest first. Empty address and source filters match every flag.
+func (r *Repository) ListFlaggedAddresses(ctx context.Context, address string, source string, beforeID int64, limit int32) ([]FlaggedAddress, error) {
+	return r.queries.ListFlaggedAddresses(ctx, ListFlaggedAddressesParams{
+		Address:  address,
+		Source:   source,
+		BeforeID: beforeID,
+		MaxFlags: limit,
+	})
+}
+
+// ErrMintJobAbandoned is returned when a mint job was abandoned, e.g. because
+// its record is being revoked, before its transaction was sent.
+var ErrMintJobAbandoned = errors.New("mint job was abandoned")
+
+// Statuses of a mint job
+const (
+	MintJobPending   = "pending"
+	MintJobSent      = "sent"
+	MintJobSucceeded = "succeeded"
+	MintJobFailed    = "failed"
+)
+
+// ClaimMintJob returns the mint job of a wallet, creating it if needed. A
+// finished job is reset to pending; an unfinished one is returned unchanged so
+// the transaction it sent can be followed up.
+func (r *Repository) ClaimMintJob(ctx context.Context, citizenID string, walletAddress string) (*MintJob, error) {
+	job, err := r.queries.ClaimMintJob(ctx, ClaimMintJobParams{
+		WalletAddress: walletAddress,
+		CitizenID:     r.cipher.BlindIndex(citizenID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListUnfinishedMintJobs returns the pending and sent mint jobs, oldest first.
+func (r *Repository) ListUnfinishedMintJobs(ctx context.Context) ([]MintJob, error) {
+	return r.queries.ListUnfinishedMintJobs(ctx)
+}
+
+// MarkMintJobSent records the transaction of a mint job. It must be called
+// before the transaction is sent, and returns ErrMintJobAbandoned when the
+// transaction must not be sent because the job was abandoned.
+func (r *Repository) MarkMintJobSent(ctx context.Context, walletAddress string, txHash string, nonce uint64) error {
+	rows, err := r.queries.MarkMintJobSent(ctx, MarkMintJobSentParams{
+		WalletAddress: walletAddress,
+		TxHash:        pgtype.Text{String: txHash, Valid: true},
+		Nonce:         pgtype.Int8{Int64: int64(nonce), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrMintJobAbandoned
+	}
+	return nil
+}
+
+// AbandonMintJob fails the mint job of a wallet unless it already sent its
+// transaction, and reports whether it did. A wallet without a job has nothing
+// to abandon.
+func (r *Repository) AbandonMintJob(ctx context.Context, walletAddress string, reason string) (bool, error) {
+	rows, err := r.queries.AbandonMintJob(ctx, AbandonMintJobParams{
+		WalletAddress: walletAddress,
+		LastError:     pgtype.Text{String: reason, Valid: reason != ""},
+	})
+	return rows > 0, err
+}
+
+// GetMintJob returns the mint job of a wallet, or pgx.ErrNoRows without one.
+func (r *Repository) GetMintJob(ctx context.Context, walletAddress string) (*MintJob, error) {
+	job, err := r.queries.GetMintJob(ctx, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// SetMintJobStatus updates the status of a mint job and its last error, which
+// is cleared when lastError is empty.
+func (r *Repository) SetMintJobStatus(ctx context.Context, walletAddress string, status string, lastError string) error {
+	return r.queries.SetMintJobStatus(ctx, SetMintJobStatusParams{
+		WalletAddress: walletAddress,
+		Status:        status,
+		LastError:     pgtype.Text{String: lastError, Valid: lastError != ""},
+	})
+}
+
+// FinishMintJob marks a mint job succeeded or failed with the outcome of its
+// last transaction, which is empty when no transaction was decisive.
+func (r *Repository) FinishMintJob(ctx context.Context, walletAddress string, status string, outcome string, lastError string) error {
+	return r.queries.FinishMintJob(ctx, FinishMintJobParams{
+		WalletAddress: walletAddress,
+		Status:        status,
+		Outcome:       pgtype.Text{String: outcome, Valid: outcome != ""},
+		LastError:     pgtype.Text{String: lastError, Valid: lastError != ""},
+	})
+}
+
+// Statuses of a burn job
+const (
+	BurnJobPending   = "pending"
+	BurnJobSucceeded = "succeeded"
+	BurnJobFailed    = "failed"
+)
+
+// QueueBurnJob records that the NFT of a wallet has to be burned. The job is
+// kept pending until the burn is confirmed.
+func (r *Repository) QueueBurnJob(ctx context.Context, citizenID string, walletAddress string) error {
+	return r.queries.QueueBurnJob(ctx, QueueBurnJobParams{
+		WalletAddress: walletAddress,
+		CitizenID:     r.cipher.BlindIndex(citizenID),
+	})
+}
+
+// ListPendingBurnJobs returns up to limit pending burn jobs, oldest first.
+func (r *Repository) ListPendingBurnJobs(ctx context.Context, limit int32) ([]BurnJob, error) {
+	return r.queries.ListPendingBurnJobs(ctx, limit)
+}
+
+// SetBurnJobStatus records an attempt of a burn job with its outcome. The
+// last error is cleared when lastError is empty.
+func (r *Repository) SetBurnJobStatus(ctx context.Context, walletAddress string, status string, lastError string) error {
+	return r.queries.SetBurnJobStatus(ctx, SetBurnJobStatusParams{
+		WalletAddress: walletAddress,
+		Status:        status,
+		LastError:     pgtype.Text{String: lastError, Valid: lastError != ""},
+	})
+}
+
+// ReserveSignerNonce returns the next nonce of an account and advances it.
+// The stored nonce is first raised to chainNonce, the pending nonce reported
+// by the chain.
+func (r *Repository) ReserveSignerNonce(ctx context.Context, chainID int64, address string, chainNonce uint64) (uint64, error) {
+	nonce, err := r.queries.ReserveSignerNonce(ctx, ReserveSignerNonceParams{
+		ChainID:    chainID,
+		Address:    address,
+		ChainNonce: int64(chainNonce),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return uint64(nonce), nil
+}
+
+// PeekSignerNonce returns the next nonce of an account without reserving it,
+// or 0 when no nonce was reserved for it yet.
+func (r *Repository) PeekSignerNonce(ctx context.Context, chainID int64, address string) (uint64, error) {
+	nonce, err := r.queries.GetSignerNonce(ctx, GetSignerNonceParams{ChainID: chainID, Address: address})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return uint64(nonce), nil
 }
@@ -11,24 +11,16 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const createOrUpdateWalletInfo = `-- name: CreateOrUpdateWalletInfo :exec
-INSERT INTO wallet_info (wallet_address, citizen_id, wallet_signature, created_at)
-VALUES ($1, $2, $3, now())
-ON CONFLICT (wallet_address) DO UPDATE
-SET citizen_id = EXCLUDED.citizen_id, 
-    wallet_signature = EXCLUDED.wallet_signature,
-    created_at = now()
+const countWalletsByCitizenID = `-- name: CountWalletsByCitizenID :one
+SELECT COUNT(*) FROM wallet_info
+WHERE citizen_id = $1
 `
 
-type CreateOrUpdateWalletInfoParams struct {
-	WalletAddress   string
-	CitizenID       pgtype.Text
-	WalletSignature pgtype.Text
-}
-
-func (q *Queries) CreateOrUpdateWalletInfo(ctx context.Context, arg CreateOrUpdateWalletInfoParams) error {
-	_, err := q.db.Exec(ctx, createOrUpdateWalletInfo, arg.WalletAddress, arg.CitizenID, arg.WalletSignature)
-	return err
+func (q *Queries) CountWalletsByCitizenID(ctx context.Context, citizenID pgtype.Text) (int64, error) {
+	row := q.db.QueryRow(ctx, countWalletsByCitizenID, citizenID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
 const createWalletInfo = `-- name: CreateWalletInfo :one
@@ -65,3 +57,108 @@ func (q *Queries) CreateWalletInfo(ctx context.Context, arg CreateWalletInfoPara
 	)
 	return i, err
 }
+
+const getWalletsByCitizenID = `-- name: GetWalletsByCitizenID :many
+SELECT wallet_address, citizen_id, wallet_signature, created_at FROM wallet_info
+WHERE citizen_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) GetWalletsByCitizenID(ctx context.Context, citizenID pgtype.Text) ([]WalletInfo, error) {
+	rows, err := q.db.Query(ctx, getWalletsByCitizenID, citizenID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WalletInfo
+	for rows.Next() {
+		var i WalletInfo
+		if err := rows.Scan(
+			&i.WalletAddress,
+			&i.CitizenID,
+			&i.WalletSignature,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const linkWalletInfo = `-- name: LinkWalletInfo :execrows
+INSERT INTO wallet_info (wallet_address, citizen_id, wallet_signature, created_at)
+SELECT $1::varchar, $2::varchar, $3::text, now()
+WHERE NOT EXISTS (
+    SELECT 1 FROM wallet_info WHERE LOWER(wallet_address) = LOWER($1)
+)
+ON CONFLICT (wallet_address) DO NOTHING
+`
+
+type LinkWalletInfoParams struct {
+	WalletAddress   string
+	CitizenID       string
+	WalletSignature string
+}
+
+// Binds a wallet to a citizen unless it is already bound, in any letter case
+func (q *Queries) LinkWalletInfo(ctx context.Context, arg LinkWalletInfoParams) (int64, error) {
+	result, err := q.db.Exec(ctx, linkWalletInfo, arg.WalletAddress, arg.CitizenID, arg.WalletSignature)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const unlinkWalletInfo = `-- name: UnlinkWalletInfo :execrows
+DELETE FROM wallet_info
+WHERE LOWER(wallet_address) = LOWER($1) AND citizen_id = $2
+`
+
+type UnlinkWalletInfoParams struct {
+	WalletAddress string
+	CitizenID     pgtype.Text
+}
+
+func (q *Queries) UnlinkWalletInfo(ctx context.Context, arg UnlinkWalletInfoParams) (int64, error) {
+	result, err := q.db.Exec(ctx, unlinkWalletInfo, arg.WalletAddress, arg.CitizenID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listWalletAddresses = `-- name: ListWalletAddresses :many
+SELECT wallet_address FROM wallet_info
+WHERE wallet_address > $1
+ORDER BY wallet_address
+LIMIT $2
+`
+
+type ListWalletAddressesParams struct {
+	WalletAddress string
+	Limit         int32
+}
+
+func (q *Queries) ListWalletAddresses(ctx context.Context, arg ListWalletAddressesParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, listWalletAddresses, arg.WalletAddress, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var wallet_address string
+		if err := rows.Scan(&wallet_address); err != nil {
+			return nil, err
+		}
+		items = append(items, wallet_address)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -11,11 +11,32 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const confirmWithdrawalsUpToBlock = `-- name: ConfirmWithdrawalsUpToBlock :execrows
+UPDATE withdrawals
+SET confirmed = TRUE
+WHERE chain_id = $1
+AND block_number <= $2
+AND NOT confirmed
+`
+
+type ConfirmWithdrawalsUpToBlockParams struct {
+	ChainID     pgtype.Int4
+	BlockNumber pgtype.Int4
+}
+
+func (q *Queries) ConfirmWithdrawalsUpToBlock(ctx context.Context, arg ConfirmWithdrawalsUpToBlockParams) (int64, error) {
+	result, err := q.db.Exec(ctx, confirmWithdrawalsUpToBlock, arg.ChainID, arg.BlockNumber)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const createWithdrawal = `-- name: CreateWithdrawal :one
-INSERT INTO withdrawals (contract_address, recipient, nullifier_hash, relayer, fee, tx_hash, timestamp, block_number, chain_id) 
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-ON CONFLICT (nullifier_hash) DO NOTHING
-RETURNING id, contract_address, recipient, nullifier_hash, relayer, fee, tx_hash, timestamp, block_number, chain_id
+INSERT INTO withdrawals (contract_address, recipient, nullifier_hash, relayer, fee, tx_hash, timestamp, block_number, chain_id, block_hash, log_index) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (chain_id, contract_address, nullifier_hash) DO NOTHING
+RETURNING id, contract_address, recipient, nullifier_hash, relayer, fee, tx_hash, timestamp, block_number, chain_id, block_hash, log_index, confirmed
 `
 
 type CreateWithdrawalParams struct {
@@ -28,6 +49,8 @@ type CreateWithdrawalParams struct {
 	Timestamp       pgtype.Numeric
 	BlockNumber     pgtype.Int4
 	ChainID         pgtype.Int4
+	BlockHash       pgtype.Text
+	LogIndex        pgtype.Int4
 }
 
 func (q *Queries) CreateWithdrawal(ctx context.Context, arg CreateWithdrawalParams) (Withdrawal, error) {
@@ -41,6 +64,8 @@ func (q *Queries) CreateWithdrawal(ctx context.Context, arg CreateWithdrawalPara
 		arg.Timestamp,
 		arg.BlockNumber,
 		arg.ChainID,
+		arg.BlockHash,
+		arg.LogIndex,
 	)
 	var i Withdrawal
 	err := row.Scan(
@@ -54,12 +79,53 @@ func (q *Queries) CreateWithdrawal(ctx context.Context, arg CreateWithdrawalPara
 		&i.Timestamp,
 		&i.BlockNumber,
 		&i.ChainID,
+		&i.BlockHash,
+		&i.LogIndex,
+		&i.Confirmed,
 	)
 	return i, err
 }
 
+const deleteWithdrawalsAfterBlock = `-- name: DeleteWithdrawalsAfterBlock :execrows
+DELETE FROM withdrawals
+WHERE chain_id = $1
+AND block_number > $2
+`
+
+type DeleteWithdrawalsAfterBlockParams struct {
+	ChainID     pgtype.Int4
+	BlockNumber pgtype.Int4
+}
+
+func (q *Queries) DeleteWithdrawalsAfterBlock(ctx context.Context, arg DeleteWithdrawalsAfterBlockParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWithdrawalsAfterBlock, arg.ChainID, arg.BlockNumber)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteWithdrawalsByBlockHash = `-- name: DeleteWithdrawalsByBlockHash :execrows
+DELETE FROM withdrawals
+WHERE chain_id = $1
+AND block_hash = $2
+`
+
+type DeleteWithdrawalsByBlockHashParams struct {
+	ChainID   pgtype.Int4
+	BlockHash pgtype.Text
+}
+
+func (q *Queries) DeleteWithdrawalsByBlockHash(ctx context.Context, arg DeleteWithdrawalsByBlockHashParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWithdrawalsByBlockHash, arg.ChainID, arg.BlockHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getAllWithdrawalsOfContract = `-- name: GetAllWithdrawalsOfContract :many
-SELECT id, contract_address, recipient, nullifier_hash, relayer, fee, tx_hash, timestamp, block_number, chain_id FROM withdrawals WHERE contract_address = $1
+SELECT id, contract_address, recipient, nullifier_hash, relayer, fee, tx_hash, timestamp, block_number, chain_id, block_hash, log_index, confirmed FROM withdrawals WHERE contract_address = $1
 ORDER BY timestamp ASC
 `
 
@@ -83,6 +149,9 @@ func (q *Queries) GetAllWithdrawalsOfContract(ctx context.Context, contractAddre
 			&i.Timestamp,
 			&i.BlockNumber,
 			&i.ChainID,
+			&i.BlockHash,
+			&i.LogIndex,
+			&i.Confirmed,
 		); err != nil {
 			return nil, err
 		}
@@ -95,7 +164,7 @@ func (q *Queries) GetAllWithdrawalsOfContract(ctx context.Context, contractAddre
 }
 
 const getAllWithdrawalsOfRecipient = `-- name: GetAllWithdrawalsOfRecipient :many
-SELECT id, contract_address, recipient, nullifier_hash, relayer, fee, tx_hash, timestamp, block_number, chain_id FROM withdrawals WHERE recipient = $1
+SELECT id, contract_address, recipient, nullifier_hash, relayer, fee, tx_hash, timestamp, block_number, chain_id, block_hash, log_index, confirmed FROM withdrawals WHERE recipient = $1
 ORDER BY timestamp ASC
 `
 
@@ -119,6 +188,9 @@ func (q *Queries) GetAllWithdrawalsOfRecipient(ctx context.Context, recipient pg
 			&i.Timestamp,
 			&i.BlockNumber,
 			&i.ChainID,
+			&i.BlockHash,
+			&i.LogIndex,
+			&i.Confirmed,
 		); err != nil {
 			return nil, err
 		}
@@ -130,6 +202,24 @@ func (q *Queries) GetAllWithdrawalsOfRecipient(ctx context.Context, recipient pg
 	return items, nil
 }
 
+const getEarliestWithdrawalSyncedBlock = `-- name: GetEarliestWithdrawalSyncedBlock :one
+SELECT MIN(block_number) FROM withdrawals
+WHERE contract_address = $1
+AND chain_id = $2
+`
+
+type GetEarliestWithdrawalSyncedBlockParams struct {
+	ContractAddress pgtype.Text
+	ChainID         pgtype.Int4
+}
+
+func (q *Queries) GetEarliestWithdrawalSyncedBlock(ctx context.Context, arg GetEarliestWithdrawalSyncedBlockParams) (interface{}, error) {
+	row := q.db.QueryRow(ctx, getEarliestWithdrawalSyncedBlock, arg.ContractAddress, arg.ChainID)
+	var min interface{}
+	err := row.Scan(&min)
+	return min, err
+}
+
 const getLatestWithdrawalSyncedBlockOfContractOnChain = `-- name: GetLatestWithdrawalSyncedBlockOfContractOnChain :one
 SELECT MAX(block_number) FROM withdrawals WHERE contract_address = $1 AND chain_id = $2
 `
@@ -147,7 +237,7 @@ func (q *Queries) GetLatestWithdrawalSyncedBlockOfContractOnChain(ctx context.Co
 }
 
 const getWithdrawalByNullifierHash = `-- name: GetWithdrawalByNullifierHash :one
-SELECT id, contract_address, recipient, nullifier_hash, relayer, fee, tx_hash, timestamp, block_number, chain_id FROM withdrawals WHERE nullifier_hash = $1
+SELECT id, contract_address, recipient, nullifier_hash, relayer, fee, tx_hash, timestamp, block_number, chain_id, block_hash, log_index, confirmed FROM withdrawals WHERE nullifier_hash = $1
 `
 
 func (q *Queries) GetWithdrawalByNullifierHash(ctx context.Context, nullifierHash pgtype.Text) (Withdrawal, error) {
@@ -164,6 +254,139 @@ func (q *Queries) GetWithdrawalByNullifierHash(ctx context.Context, nullifierHas
 		&i.Timestamp,
 		&i.BlockNumber,
 		&i.ChainID,
+		&i.BlockHash,
+		&i.LogIndex,
+		&i.Confirmed,
 	)
 	return i, err
 }
+
+const getWithdrawalsPageAsc = `-- name: GetWithdrawalsPageAsc :many
+SELECT id, contract_address, recipient, nullifier_hash, relayer, fee, tx_hash, timestamp, block_number, chain_id, block_hash, log_index, confirmed FROM withdrawals
+WHERE contract_address = $1
+AND chain_id = $2
+AND block_number BETWEEN $3 AND $4
+AND (confirmed OR NOT $5::boolean)
+AND (block_number, COALESCE(log_index, -1)) > ($6::int, $7::int)
+ORDER BY block_number ASC, COALESCE(log_index, -1) ASC
+LIMIT $8
+`
+
+type GetWithdrawalsPageAscParams struct {
+	ContractAddress pgtype.Text
+	ChainID         pgtype.Int4
+	FromBlock       pgtype.Int4
+	ToBlock         pgtype.Int4
+	ConfirmedOnly   bool
+	CursorBlock     int32
+	CursorLogIndex  int32
+	PageSize        int32
+}
+
+func (q *Queries) GetWithdrawalsPageAsc(ctx context.Context, arg GetWithdrawalsPageAscParams) ([]Withdrawal, error) {
+	rows, err := q.db.Query(ctx, getWithdrawalsPageAsc,
+		arg.ContractAddress,
+		arg.ChainID,
+		arg.FromBlock,
+		arg.ToBlock,
+		arg.ConfirmedOnly,
+		arg.CursorBlock,
+		arg.CursorLogIndex,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Withdrawal
+	for rows.Next() {
+		var i Withdrawal
+		if err := rows.Scan(
+			&i.ID,
+			&i.ContractAddress,
+			&i.Recipient,
+			&i.NullifierHash,
+			&i.Relayer,
+			&i.Fee,
+			&i.TxHash,
+			&i.Timestamp,
+			&i.BlockNumber,
+			&i.ChainID,
+			&i.BlockHash,
+			&i.LogIndex,
+			&i.Confirmed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWithdrawalsPageDesc = `-- name: GetWithdrawalsPageDesc :many
+SELECT id, contract_address, recipient, nullifier_hash, relayer, fee, tx_hash, timestamp, block_number, chain_id, block_hash, log_index, confirmed FROM withdrawals
+WHERE contract_address = $1
+AND chain_id = $2
+AND block_number BETWEEN $3 AND $4
+AND (confirmed OR NOT $5::boolean)
+AND (block_number, COALESCE(log_index, -1)) < ($6::int, $7::int)
+ORDER BY block_number DESC, COALESCE(log_index, -1) DESC
+LIMIT $8
+`
+
+type GetWithdrawalsPageDescParams struct {
+	ContractAddress pgtype.Text
+	ChainID         pgtype.Int4
+	FromBlock       pgtype.Int4
+	ToBlock         pgtype.Int4
+	ConfirmedOnly   bool
+	CursorBlock     int32
+	CursorLogIndex  int32
+	PageSize        int32
+}
+
+func (q *Queries) GetWithdrawalsPageDesc(ctx context.Context, arg GetWithdrawalsPageDescParams) ([]Withdrawal, error) {
+	rows, err := q.db.Query(ctx, getWithdrawalsPageDesc,
+		arg.ContractAddress,
+		arg.ChainID,
+		arg.FromBlock,
+		arg.ToBlock,
+		arg.ConfirmedOnly,
+		arg.CursorBlock,
+		arg.CursorLogIndex,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Withdrawal
+	for rows.Next() {
+		var i Withdrawal
+		if err := rows.Scan(
+			&i.ID,
+			&i.ContractAddress,
+			&i.Recipient,
+			&i.NullifierHash,
+			&i.Relayer,
+			&i.Fee,
+			&i.TxHash,
+			&i.Timestamp,
+			&i.BlockNumber,
+			&i.ChainID,
+			&i.BlockHash,
+			&i.LogIndex,
+			&i.Confirmed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
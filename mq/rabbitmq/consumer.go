@@ -100,6 +100,41 @@ func (c *Consumer) Consume(handler func(MQMessage)) error {
 	return nil
 }
 
+// ConsumeWithAck is like Consume, but only acks a message once handler
+// returns nil. A failed message is requeued once and dropped when it fails
+// again, so handlers must keep the state needed to retry it themselves.
+func (c *Consumer) ConsumeWithAck(handler func(MQMessage) error) error {
+	msgs, err := c.channel.Consume(
+		c.queueName, // queue
+		"",          // consumer
+		false,       // auto-ack
+		false,       // exclusive
+		false,       // no-local
+		false,       // no-wait
+		nil,         // args
+	)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for d := range msgs {
+			var msg MQMessage
+			if err := json.Unmarshal(d.Body, &msg); err != nil {
+				log.Println("Error unmarshalling message:", err)
+				d.Nack(false, false)
+				continue
+			}
+			if err := handler(msg); err != nil {
+				log.Printf("Failed to handle %s message (redelivered: %t): %v", msg.Type, d.Redelivered, err)
+				d.Nack(false, !d.Redelivered)
+				continue
+			}
+			d.Ack(false)
+		}
+	}()
+	return nil
+}
+
 func (c *Consumer) Close() {
 	if c.channel != nil {
 		_ = c.channel.Close()
@@ -0,0 +1,105 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Exchange and routing keys used by the blockchain listener
+const (
+	BlockchainExchange = "blockchain_exchange"
+
+	RoutingKeyDeposit    = "listener.deposit"
+	RoutingKeyWithdrawal = "listener.withdraw"
+	RoutingKeyReorg      = "listener.reorg"
+)
+
+// DepositEvent is published once for every deposit stored by the listener.
+// (chain_id, tx_hash, log_index) identifies the event and can be used to
+// drop redeliveries.
+type DepositEvent struct {
+	ChainID         int32  `json:"chain_id"`
+	ContractAddress string `json:"contract_address"`
+	Commitment      string `json:"commitment"`
+	Depositor       string `json:"depositor"`
+	LeafIndex       uint32 `json:"leaf_index"`
+	Timestamp       string `json:"timestamp"`
+	TxHash          string `json:"tx_hash"`
+	BlockNumber     uint64 `json:"block_number"`
+	BlockHash       string `json:"block_hash"`
+	LogIndex        uint   `json:"log_index"`
+}
+
+// WithdrawalEvent is published once for every withdrawal stored by the listener.
+type WithdrawalEvent struct {
+	ChainID         int32  `json:"chain_id"`
+	ContractAddress string `json:"contract_address"`
+	NullifierHash   string `json:"nullifier_hash"`
+	Recipient       string `json:"recipient"`
+	Relayer         string `json:"relayer"`
+	Fee             string `json:"fee"`
+	TxHash          string `json:"tx_hash"`
+	BlockNumber     uint64 `json:"block_number"`
+	BlockHash       string `json:"block_hash"`
+	LogIndex        uint   `json:"log_index"`
+}
+
+// ReorgEvent is published when previously announced events were dropped
+// because their block left the canonical chain. Either AfterBlock (everything
+// above it was removed) or BlockHash (that single block was removed) is set.
+type ReorgEvent struct {
+	ChainID     int32  `json:"chain_id"`
+	AfterBlock  uint64 `json:"after_block,omitempty"`
+	BlockHash   string `json:"block_hash,omitempty"`
+	Deposits    int64  `json:"deposits"`
+	Withdrawals int64  `json:"withdrawals"`
+}
+
+// Exchange and routing keys used by the KYC workflow and the alerts of the
+// common service
+const (
+	KycExchange = "kyc-mint-exchange"
+
+	RoutingKeyKycExpiring          = "kyc.expiring"
+	RoutingKeyScreeningFlagged     = "screening.flagged"
+	RoutingKeyMerkleRootDivergence = "merkle.divergence"
+)
+
+// KycExpiringEvent is published once ahead of the expiry of a KYC record, so
+// the holder of its wallets can be asked to verify again.
+type KycExpiringEvent struct {
+	WalletAddresses []string  `json:"wallet_addresses"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// ScreeningAlertEvent is published when an address seen in a deposit, a
+// withdrawal or a KYC wallet matches a sanctions denylist for the first time.
+type ScreeningAlertEvent struct {
+	Address   string `json:"address"`
+	Source    string `json:"source"`
+	ListName  string `json:"list_name"`
+	ListEntry string `json:"list_entry,omitempty"`
+	ChainID   int32  `json:"chain_id,omitempty"`
+	TxHash    string `json:"tx_hash,omitempty"`
+}
+
+// MerkleRootAlertEvent is published when the deposit tree computed for a mixer
+// contract stops matching the contract's own root.
+type MerkleRootAlertEvent struct {
+	ChainID         int32  `json:"chain_id"`
+	ContractAddress string `json:"contract_address"`
+	Reason          string `json:"reason"`
+	Root            string `json:"root"`
+	Leaves          int    `json:"leaves"`
+	OnChainRoot     string `json:"on_chain_root"`
+	OnChainLeaves   uint32 `json:"on_chain_leaves"`
+}
+
+// Decode unmarshals the message data into v.
+func (m MQMessage) Decode(v interface{}) error {
+	b, err := json.Marshal(m.Data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -1,6 +1,8 @@
 package rabbitmq
 
 import (
+	"sync"
+
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -14,6 +16,9 @@ type Producer struct {
     channel     *amqp.Channel
     exchange    string
     exchangeType string
+    confirms    bool // wait for broker acks, see EnableConfirms
+    returns     chan amqp.Return // unroutable messages sent back in confirm mode
+    mu          sync.Mutex // one confirmed publish in flight, so a return belongs to it
 }
 
 type Consumer struct {
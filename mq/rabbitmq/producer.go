@@ -1,7 +1,9 @@
 package rabbitmq
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
@@ -39,7 +41,25 @@ func NewProducer(url, exchange, exchangeType string) (*Producer, error) {
 	}, nil
 }
 
+// EnableConfirms puts the channel in confirm mode: from then on PublishStruct
+// only returns once the broker has taken responsibility for the message.
+// Messages are published as mandatory, so one that no queue is bound for is
+// returned by the broker and its publish fails instead of being lost.
+func (p *Producer) EnableConfirms() error {
+	if err := p.channel.Confirm(false); err != nil {
+		return err
+	}
+	p.returns = p.channel.NotifyReturn(make(chan amqp.Return, 1))
+	p.confirms = true
+	return nil
+}
+
 func (p *Producer) PublishStruct(routingKey string, data interface{}) error {
+	return p.PublishStructContext(context.Background(), routingKey, data)
+}
+
+// PublishStructContext publishes data like PublishStruct, giving up when ctx is done.
+func (p *Producer) PublishStructContext(ctx context.Context, routingKey string, data interface{}) error {
 	msg := MQMessage{
 		Type: routingKey,
 		Data: data,
@@ -48,16 +68,39 @@ func (p *Producer) PublishStruct(routingKey string, data interface{}) error {
 	if err != nil {
 		return err
 	}
-	return p.channel.Publish(
+	if p.confirms {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+	}
+	confirmation, err := p.channel.PublishWithDeferredConfirmWithContext(
+		ctx,
 		p.exchange,
 		routingKey,
-		true,  // mandatory
-		false, // immediate
+		p.confirms, // mandatory, returns are only watched in confirm mode
+		false,      // immediate
 		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Body:         body,
 		},
 	)
+	if err != nil || !p.confirms {
+		return err
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return fmt.Errorf("message %s was nacked by the broker", routingKey)
+	}
+	// The broker sends a return before the ack of the same message
+	select {
+	case ret := <-p.returns:
+		return fmt.Errorf("message %s was returned by the broker: %s", routingKey, ret.ReplyText)
+	default:
+	}
+	return nil
 }
 
 func (p *Producer) Close() {
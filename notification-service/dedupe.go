@@ -0,0 +1,42 @@
+package main
+
+import "fmt"
+
+// seenEventsLimit bounds how many delivered events are remembered. Redeliveries
+// follow the original closely, so only recent events need to be kept.
+const seenEventsLimit = 10000
+
+// seenEvents remembers the chain events already handled, keyed by
+// (chain_id, tx_hash, log_index), so an event delivered more than once is only
+// notified once. The oldest keys are forgotten first.
+type seenEvents struct {
+	keys  map[string]struct{}
+	order []string
+}
+
+func newSeenEvents() *seenEvents {
+	return &seenEvents{keys: make(map[string]struct{})}
+}
+
+func eventKey(chainID int32, txHash string, logIndex uint) string {
+	return fmt.Sprintf("%d:%s:%d", chainID, txHash, logIndex)
+}
+
+// Seen reports whether key was already added.
+func (s *seenEvents) Seen(key string) bool {
+	_, ok := s.keys[key]
+	return ok
+}
+
+// Add records key, dropping the oldest key once the limit is reached.
+func (s *seenEvents) Add(key string) {
+	if s.Seen(key) {
+		return
+	}
+	if len(s.order) >= seenEventsLimit {
+		delete(s.keys, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.keys[key] = struct{}{}
+	s.order = append(s.order, key)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"os"
+	"strings"
 
 	"github.com/yourusername/yourrepo/mq/rabbitmq"
 )
@@ -25,6 +26,31 @@ func main() {
 	}
 	defer consumer.Close()
 
+	// Withdrawals indexed by the blockchain listener, used to see which of our
+	// relays landed on chain
+	relayerAddress := os.Getenv("RELAYER_ADDRESS")
+	chainConsumer, err := rabbitmq.NewConsumer(rabbitmqURL, rabbitmq.BlockchainExchange, "topic", "relayer_chain_events", []string{rabbitmq.RoutingKeyWithdrawal})
+	if err != nil {
+		log.Fatalf("Failed to initialize RabbitMQ consumer: %v", err)
+	}
+	defer chainConsumer.Close()
+
+	err = chainConsumer.Consume(func(message rabbitmq.MQMessage) {
+		var withdrawal rabbitmq.WithdrawalEvent
+		if err := message.Decode(&withdrawal); err != nil {
+			log.Printf("Invalid withdrawal event: %v", err)
+			return
+		}
+		if relayerAddress != "" && !strings.EqualFold(withdrawal.Relayer, relayerAddress) {
+			return
+		}
+		log.Printf("Relayed withdrawal indexed on chain %d: nullifierHash=%s, fee=%s, txHash=%s",
+			withdrawal.ChainID, withdrawal.NullifierHash, withdrawal.Fee, withdrawal.TxHash)
+	})
+	if err != nil {
+		log.Printf("Failed to consume chain events: %v", err)
+	}
+
 	// Start consuming messages
 	log.Println("Relayer Service is consuming messages from relayer_events...")
 	err = consumer.Consume(func(message rabbitmq.MQMessage) {